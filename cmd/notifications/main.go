@@ -0,0 +1,427 @@
+// notifications is a command-line tool for working with notifications stores.
+//
+// It operates on an fs store directory, or on GitHub notifications
+// of the user whose token is in the GITHUB_TOKEN environment variable.
+//
+// Usage:
+//
+//	notifications [flags] <command> [command flags] [args]
+//
+// The commands are:
+//
+//	list           list notifications
+//	count          count unread notifications
+//	mark-read      mark a thread read
+//	mark-all-read  mark all notifications read
+//	subscribe      subscribe users to a thread
+//	notify         notify subscribers of a thread
+//	copy           copy notifications from another store into this one
+//
+// For example, to list unread notifications of user 1@example.org in a store:
+//
+//	notifications -dir=/var/lib/notifications -user=1@example.org list
+//
+// The -json flag can be given before or after the command:
+//
+//	notifications -dir=/var/lib/notifications -user=1@example.org list -json
+//
+// Use "notifications <command> -h" for the flags of a command.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	githubv3 "github.com/google/go-github/github"
+	"github.com/shurcooL/githubv4"
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/fs"
+	"github.com/shurcooL/notifications/githubapi"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/users"
+	"golang.org/x/net/webdav"
+)
+
+var (
+	dirFlag    = flag.String("dir", "", "Path to fs store directory. Mutually exclusive with -github.")
+	githubFlag = flag.Bool("github", false, "Use GitHub notifications of the user whose token is in GITHUB_TOKEN environment variable.")
+	userFlag   = flag.String("user", "", "Authenticated user for fs store, like 1@example.org.")
+	jsonFlag   = flag.Bool("json", false, "Output JSON instead of a table. Also accepted as a command flag.")
+)
+
+// commands are the subcommands, by name.
+var commands = map[string]func(ctx context.Context, s notifications.Service, args []string) error{
+	"list":          list,
+	"count":         count,
+	"mark-read":     markRead,
+	"mark-all-read": markAllRead,
+	"subscribe":     subscribe,
+	"notify":        notify,
+	"copy":          copyFrom,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: notifications [flags] <command> [command flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands: list, count, mark-read, mark-all-read, subscribe, notify, copy.")
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "notifications: unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s, err := newService(*dirFlag, *githubFlag, *userFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "notifications:", err)
+		os.Exit(2)
+	}
+	err = cmd(context.Background(), s, flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notifications %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// newService returns the service backed by an fs store in dir, acting as user,
+// or if github is true, the GitHub service.
+func newService(dir string, github bool, user string) (notifications.Service, error) {
+	switch {
+	case dir != "" && github:
+		return nil, fmt.Errorf("-dir and -github are mutually exclusive")
+	case dir != "":
+		if user == "" {
+			return nil, fmt.Errorf("-user is required with -dir")
+		}
+		us, err := parseUserSpec(user)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -user: %v", err)
+		}
+		if fi, err := os.Stat(dir); err != nil {
+			return nil, err
+		} else if !fi.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		return fs.NewService(webdav.Dir(dir), storeUsers{current: us}), nil
+	case github:
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("GITHUB_TOKEN environment variable is not set")
+		}
+		httpClient := &http.Client{Transport: tokenTransport{token: token}}
+		return githubapi.NewService(githubv3.NewClient(httpClient), githubv4.NewClient(httpClient), nil), nil
+	default:
+		return nil, fmt.Errorf("one of -dir or -github is required")
+	}
+}
+
+func list(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	repo := flags.String("repo", "", "Only list notifications from this repo.")
+	all := flags.Bool("all", false, "List read notifications too.")
+	pageSize := flags.Int("page-size", 0, "Maximum number of notifications to list. 0 means all.")
+	cursor := flags.String("cursor", "", "Cursor of the page to list, as printed by a previous list.")
+	since := flags.String("since", "", "Only list notifications updated at or after this RFC 3339 time.")
+	before := flags.String("before", "", "Only list notifications updated before this RFC 3339 time.")
+	threadType := flags.String("thread-type", "", "Only list notifications of this thread type.")
+	reason := flags.String("reason", "", "Only list notifications with this reason.")
+	participating := flags.Bool("participating", false, "Only list notifications of threads the user is participating in.")
+	mentioned := flags.Bool("mentioned", false, "Only list notifications where the user was mentioned.")
+	saved := flags.Bool("saved", false, "Only list saved notifications.")
+	actor := flags.String("actor", "", "Only list notifications with this actor, like 1@example.org.")
+	jsonOutput := jsonFlagVar(flags)
+	flags.Parse(args)
+
+	opt := notifications.ListOptions{
+		All:           *all,
+		PageSize:      *pageSize,
+		Cursor:        *cursor,
+		ThreadType:    *threadType,
+		Reason:        notifications.Reason(*reason),
+		Participating: *participating,
+		Mentioned:     *mentioned,
+		Saved:         *saved,
+	}
+	if *repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: *repo}
+	}
+	var err error
+	if opt.Since, err = parseTime(*since); err != nil {
+		return fmt.Errorf("invalid -since: %v", err)
+	}
+	if opt.Before, err = parseTime(*before); err != nil {
+		return fmt.Errorf("invalid -before: %v", err)
+	}
+	if *actor != "" {
+		us, err := parseUserSpec(*actor)
+		if err != nil {
+			return fmt.Errorf("invalid -actor: %v", err)
+		}
+		opt.Actor = &us
+	}
+
+	ns, nextCursor, err := s.List(ctx, opt)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		resp := httproute.ListResponse{Notifications: []httproute.Notification{}, NextCursor: nextCursor}
+		for _, n := range ns {
+			resp.Notifications = append(resp.Notifications, httproute.FromNotification(n))
+		}
+		return printJSON(resp)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tTHREAD\tREAD\tUPDATED\tACTOR\tREASON\tTITLE")
+	for _, n := range ns {
+		fmt.Fprintf(tw, "%s\t%s/%d\t%v\t%s\t%s\t%s\t%s\n",
+			n.RepoSpec.URI, n.ThreadType, n.ThreadID, n.Read,
+			n.UpdatedAt.Local().Format("2006-01-02 15:04"), n.Actor.Login, n.Reason, n.Title)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if nextCursor != "" {
+		fmt.Printf("\nMore notifications with -cursor=%q.\n", nextCursor)
+	}
+	return nil
+}
+
+func count(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("count", flag.ExitOnError)
+	repo := flags.String("repo", "", "Only count notifications from this repo.")
+	threadType := flags.String("thread-type", "", "Only count notifications of this thread type.")
+	participating := flags.Bool("participating", false, "Only count notifications of threads the user is participating in.")
+	mentioned := flags.Bool("mentioned", false, "Only count notifications where the user was mentioned.")
+	jsonOutput := jsonFlagVar(flags)
+	flags.Parse(args)
+	opt := notifications.CountOptions{
+		ThreadType:    *threadType,
+		Participating: *participating,
+		Mentioned:     *mentioned,
+	}
+	if *repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: *repo}
+	}
+	n, err := s.Count(ctx, opt)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		return printJSON(httproute.CountResponse{Count: n})
+	}
+	fmt.Println(n)
+	return nil
+}
+
+func markRead(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("mark-read", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, "Usage: notifications mark-read <repo> <thread-type> <thread-id>") }
+	flags.Parse(args)
+	t, err := parseThread(flags.Args())
+	if err != nil {
+		flags.Usage()
+		return err
+	}
+	return s.MarkRead(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID)
+}
+
+func markAllRead(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("mark-all-read", flag.ExitOnError)
+	repo := flags.String("repo", "", "Only mark notifications from this repo. Empty means all repos.")
+	lastReadAt := flags.String("last-read-at", "", "Only mark notifications that arrived at or before this RFC 3339 time. Empty means now.")
+	flags.Parse(args)
+	var opt notifications.MarkAllReadOptions
+	if *repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: *repo}
+	}
+	var err error
+	if opt.LastReadAt, err = parseTime(*lastReadAt); err != nil {
+		return fmt.Errorf("invalid -last-read-at: %v", err)
+	}
+	return s.MarkAllRead(ctx, opt)
+}
+
+func subscribe(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("subscribe", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: notifications subscribe <repo> <thread-type> <thread-id> <user>...")
+		fmt.Fprintln(os.Stderr, "Users are like 1@example.org. A thread-id of 0 subscribes to the entire repo.")
+	}
+	flags.Parse(args)
+	if flags.NArg() < 4 {
+		flags.Usage()
+		return fmt.Errorf("want at least 4 arguments, got %d", flags.NArg())
+	}
+	t, err := parseThread(flags.Args()[:3])
+	if err != nil {
+		flags.Usage()
+		return err
+	}
+	var subscribers []users.UserSpec
+	for _, arg := range flags.Args()[3:] {
+		us, err := parseUserSpec(arg)
+		if err != nil {
+			return fmt.Errorf("invalid user %q: %v", arg, err)
+		}
+		subscribers = append(subscribers, us)
+	}
+	return s.Subscribe(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID, subscribers)
+}
+
+func notify(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("notify", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: notifications notify [flags] <repo> <thread-type> <thread-id>")
+		flags.PrintDefaults()
+	}
+	title := flags.String("title", "", "Title of the notification. Required.")
+	kind := flags.String("kind", "", "Kind of the notification subject, like issue-open.")
+	state := flags.String("state", "", "State of the notification subject, like open.")
+	color := flags.String("color", "#000000", "Color of the notification icon, like #6cc644.")
+	actor := flags.String("actor", "", "User that caused the notification, like 1@example.org. Required.")
+	htmlURL := flags.String("html-url", "", "Link to the notification subject.")
+	body := flags.String("body", "", "Excerpt of the activity that caused the notification.")
+	reason := flags.String("reason", "", "Reason for the notification, like mention.")
+	mentions := flags.String("mentions", "", "Comma-separated users mentioned by the activity, like 1@example.org,2@example.org.")
+	flags.Parse(args)
+	t, err := parseThread(flags.Args())
+	if err != nil {
+		flags.Usage()
+		return err
+	}
+	if *title == "" || *actor == "" {
+		flags.Usage()
+		return fmt.Errorf("-title and -actor are required")
+	}
+	nr := notifications.NotificationRequest{
+		Title:     *title,
+		Kind:      notifications.Kind(*kind),
+		State:     notifications.SubjectState(*state),
+		UpdatedAt: time.Now(),
+		HTMLURL:   *htmlURL,
+		Body:      *body,
+		Reason:    notifications.Reason(*reason),
+	}
+	if nr.Color, err = parseColor(*color); err != nil {
+		return fmt.Errorf("invalid -color: %v", err)
+	}
+	if nr.Actor, err = parseUserSpec(*actor); err != nil {
+		return fmt.Errorf("invalid -actor: %v", err)
+	}
+	if *mentions != "" {
+		for _, m := range strings.Split(*mentions, ",") {
+			us, err := parseUserSpec(m)
+			if err != nil {
+				return fmt.Errorf("invalid -mentions: %v", err)
+			}
+			nr.Mentions = append(nr.Mentions, us)
+		}
+	}
+	return s.Notify(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID, nr)
+}
+
+func copyFrom(ctx context.Context, s notifications.Service, args []string) error {
+	flags := flag.NewFlagSet("copy", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: notifications [flags] copy [copy flags]")
+		fmt.Fprintln(os.Stderr, "Copies notifications of the source user into those of -user in this store.")
+		flags.PrintDefaults()
+	}
+	srcDir := flags.String("src-dir", "", "Path to source fs store directory. Mutually exclusive with -src-github.")
+	srcGitHub := flags.Bool("src-github", false, "Copy GitHub notifications of the user whose token is in GITHUB_TOKEN environment variable.")
+	srcUser := flags.String("src-user", "", "Source user in -src-dir store, like 1@example.org.")
+	flags.Parse(args)
+	copier, ok := s.(notifications.CopierFrom)
+	if !ok {
+		return fmt.Errorf("this store doesn't support copying notifications into it")
+	}
+	dst, err := parseUserSpec(*userFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -user: %v", err)
+	}
+	src, err := newService(*srcDir, *srcGitHub, *srcUser)
+	if err != nil {
+		return err
+	}
+	return copier.CopyFrom(ctx, src, dst)
+}
+
+// jsonFlagVar defines the -json flag of a command in flags.
+// It defaults to the value of the top-level -json flag.
+func jsonFlagVar(flags *flag.FlagSet) *bool {
+	return flags.Bool("json", *jsonFlag, "Output JSON instead of a table.")
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
+
+// parseThread parses args like "example.org/repo issues 1".
+func parseThread(args []string) (httproute.Thread, error) {
+	if len(args) != 3 {
+		return httproute.Thread{}, fmt.Errorf("want 3 arguments (repo, thread type, thread ID), got %d", len(args))
+	}
+	if args[0] == "" {
+		return httproute.Thread{}, fmt.Errorf("empty repo")
+	}
+	id, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return httproute.Thread{}, fmt.Errorf("invalid thread ID: %v", err)
+	}
+	return httproute.Thread{Repo: args[0], ThreadType: args[1], ThreadID: id}, nil
+}
+
+// parseUserSpec parses a user spec like "1@example.org".
+func parseUserSpec(s string) (users.UserSpec, error) {
+	i := strings.Index(s, "@")
+	if i == -1 {
+		return users.UserSpec{}, fmt.Errorf("user %q is not like 1@example.org", s)
+	}
+	id, err := strconv.ParseUint(s[:i], 10, 64)
+	if err != nil || id == 0 || s[i+1:] == "" {
+		return users.UserSpec{}, fmt.Errorf("user %q is not like 1@example.org", s)
+	}
+	return users.UserSpec{ID: id, Domain: s[i+1:]}, nil
+}
+
+// parseTime parses an RFC 3339 time. An empty s is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseColor parses a hexadecimal color like "#6cc644".
+func parseColor(s string) (notifications.RGB, error) {
+	if len(s) != len("#rrggbb") || s[0] != '#' {
+		return notifications.RGB{}, fmt.Errorf("color %q is not like #6cc644", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return notifications.RGB{}, fmt.Errorf("color %q is not like #6cc644", s)
+	}
+	return notifications.RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/users"
+)
+
+func TestParseUserSpec(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    users.UserSpec
+		wantErr bool
+	}{
+		{in: "1@example.org", want: users.UserSpec{ID: 1, Domain: "example.org"}},
+		{in: "123@github.com", want: users.UserSpec{ID: 123, Domain: "github.com"}},
+		{in: "", wantErr: true},
+		{in: "example.org", wantErr: true},
+		{in: "1", wantErr: true},
+		{in: "1@", wantErr: true},
+		{in: "@example.org", wantErr: true},
+		{in: "0@example.org", wantErr: true},
+		{in: "-1@example.org", wantErr: true},
+		{in: "gopher@example.org", wantErr: true},
+	} {
+		got, err := parseUserSpec(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("parseUserSpec(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseUserSpec(%q): got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    notifications.RGB
+		wantErr bool
+	}{
+		{in: "#6cc644", want: notifications.RGB{R: 0x6c, G: 0xc6, B: 0x44}},
+		{in: "#FFFFFF", want: notifications.RGB{R: 0xff, G: 0xff, B: 0xff}},
+		{in: "#000000", want: notifications.RGB{}},
+		{in: "", wantErr: true},
+		{in: "6cc644", wantErr: true},
+		{in: "#6cc64", wantErr: true},
+		{in: "#6cc6444", wantErr: true},
+		{in: "#6cc64g", wantErr: true},
+		{in: "#+cc644", wantErr: true},
+	} {
+		got, err := parseColor(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("parseColor(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseColor(%q): got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseThread(t *testing.T) {
+	for _, tc := range []struct {
+		in      []string
+		want    httproute.Thread
+		wantErr bool
+	}{
+		{in: []string{"example.org/repo", "issues", "1"}, want: httproute.Thread{Repo: "example.org/repo", ThreadType: "issues", ThreadID: 1}},
+		{in: []string{"example.org/repo", "", "0"}, want: httproute.Thread{Repo: "example.org/repo"}},
+		{in: nil, wantErr: true},
+		{in: []string{"example.org/repo", "issues"}, wantErr: true},
+		{in: []string{"example.org/repo", "issues", "1", "2"}, wantErr: true},
+		{in: []string{"", "issues", "1"}, wantErr: true},
+		{in: []string{"example.org/repo", "issues", "-1"}, wantErr: true},
+		{in: []string{"example.org/repo", "issues", "one"}, wantErr: true},
+	} {
+		got, err := parseThread(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("parseThread(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseThread(%q): got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "2018-01-02T03:04:05Z", want: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{in: "2018-01-02T03:04:05+02:00", want: time.Date(2018, 1, 2, 1, 4, 5, 0, time.UTC)},
+		{in: "2018-01-02", wantErr: true},
+		{in: "2018-01-02 03:04:05", wantErr: true},
+		{in: "yesterday", wantErr: true},
+	} {
+		got, err := parseTime(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("parseTime(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("parseTime(%q): got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNewService(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	err := os.WriteFile(file, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+
+	for _, tc := range []struct {
+		name    string
+		dir     string
+		github  bool
+		user    string
+		wantErr bool
+	}{
+		{name: "dir", dir: dir, user: "1@example.org"},
+		{name: "neither", wantErr: true},
+		{name: "both", dir: dir, github: true, user: "1@example.org", wantErr: true},
+		{name: "dir without user", dir: dir, wantErr: true},
+		{name: "dir with invalid user", dir: dir, user: "gopher", wantErr: true},
+		{name: "missing dir", dir: filepath.Join(dir, "missing"), user: "1@example.org", wantErr: true},
+		{name: "dir is a file", dir: file, user: "1@example.org", wantErr: true},
+		{name: "github without token", github: true, wantErr: true},
+	} {
+		s, err := newService(tc.dir, tc.github, tc.user)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && s == nil {
+			t.Errorf("%s: got nil service", tc.name)
+		}
+	}
+
+	t.Setenv("GITHUB_TOKEN", "token")
+	if _, err := newService("", true, ""); err != nil {
+		t.Errorf("github with token: got error %v", err)
+	}
+}
+
+func TestJSONFlagVar(t *testing.T) {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	jsonOutput := jsonFlagVar(flags)
+	err := flags.Parse([]string{"-json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*jsonOutput {
+		t.Error("want -json after the command to be accepted")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/shurcooL/users"
+)
+
+// storeUsers is a users.Service for working with an fs store directly.
+// The authenticated user is current. There's no user directory,
+// so users are known only by their specs.
+type storeUsers struct {
+	current users.UserSpec
+}
+
+func (storeUsers) Get(_ context.Context, user users.UserSpec) (users.User, error) {
+	return users.User{
+		UserSpec: user,
+		Login:    fmt.Sprintf("%d@%s", user.ID, user.Domain),
+	}, nil
+}
+
+func (u storeUsers) GetAuthenticatedSpec(context.Context) (users.UserSpec, error) {
+	return u.current, nil
+}
+
+func (u storeUsers) GetAuthenticated(ctx context.Context) (users.User, error) {
+	return u.Get(ctx, u.current)
+}
+
+func (storeUsers) Edit(context.Context, users.EditRequest) (users.User, error) {
+	return users.User{}, fmt.Errorf("editing users is not supported")
+}
+
+// tokenTransport is an http.RoundTripper that authenticates
+// requests with a GitHub personal access token.
+type tokenTransport struct {
+	token string
+}
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
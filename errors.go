@@ -0,0 +1,11 @@
+package notifications
+
+import "errors"
+
+// ErrInvalidCursor is returned by List when ListOptions.Cursor
+// is not a cursor returned by a previous List call.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrNotSupported is returned by services for operations they don't support,
+// such as ones their backing store has no way of doing.
+var ErrNotSupported = errors.New("operation not supported")
@@ -0,0 +1,215 @@
+// Package feed renders notifications as Atom 1.0 and RSS 2.0 feeds,
+// and contains an HTTP handler that serves them.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+)
+
+// Feed describes a feed of notifications.
+type Feed struct {
+	Title string // Title of the feed.
+	Link  string // Absolute URL of the web page with the notifications. Relative HTMLURLs are resolved against it.
+
+	// ID is the absolute IRI that identifies the feed in Atom.
+	// If empty, Link is used.
+	ID string
+
+	// Updated is when the feed was last updated.
+	// If zero, the latest UpdatedAt of the notifications is used.
+	Updated time.Time
+}
+
+// EntryID returns the stable ID of the feed entry for a thread.
+// It stays the same across updates to the thread's notification.
+func EntryID(repo notifications.RepoSpec, threadType string, threadID uint64) string {
+	return fmt.Sprintf("urn:x-notifications:%s:%s:%d", url.PathEscape(repo.URI), url.PathEscape(threadType), threadID)
+}
+
+// Atom writes ns to w as an Atom 1.0 feed.
+func Atom(w io.Writer, f Feed, ns notifications.Notifications) error {
+	feed := atomFeed{
+		Title:   f.Title,
+		ID:      f.ID,
+		Links:   []atomLink{{Rel: "alternate", Href: f.Link}},
+		Updated: f.updated(ns).Format(time.RFC3339),
+	}
+	if feed.ID == "" {
+		feed.ID = f.Link
+	}
+	for _, n := range ns {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:    n.Title,
+			ID:       EntryID(n.RepoSpec, n.ThreadType, n.ThreadID),
+			Links:    []atomLink{{Rel: "alternate", Href: f.resolve(n.HTMLURL)}},
+			Updated:  n.UpdatedAt.UTC().Format(time.RFC3339),
+			Author:   atomPerson{Name: name(n.Actor), URI: n.Actor.HTMLURL, Email: n.Actor.Email},
+			Category: atomCategory{Term: readState(n.Read)},
+			Summary:  n.Body,
+		})
+	}
+	return encode(w, feed)
+}
+
+// RSS writes ns to w as an RSS 2.0 feed.
+func RSS(w io.Writer, f Feed, ns notifications.Notifications) error {
+	feed := rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.updated(ns).Format(time.RFC1123Z),
+		},
+	}
+	for _, n := range ns {
+		item := rssItem{
+			Title:       n.Title,
+			Link:        f.resolve(n.HTMLURL),
+			GUID:        rssGUID{IsPermaLink: false, ID: EntryID(n.RepoSpec, n.ThreadType, n.ThreadID)},
+			PubDate:     n.UpdatedAt.UTC().Format(time.RFC1123Z),
+			Creator:     name(n.Actor),
+			Category:    readState(n.Read),
+			Description: n.Body,
+		}
+		if n.Actor.Email != "" {
+			item.Author = fmt.Sprintf("%s (%s)", n.Actor.Email, name(n.Actor))
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return encode(w, feed)
+}
+
+// updated returns when the feed with ns was last updated.
+func (f Feed) updated(ns notifications.Notifications) time.Time {
+	if !f.Updated.IsZero() {
+		return f.Updated.UTC()
+	}
+	var t time.Time
+	for _, n := range ns {
+		if n.UpdatedAt.After(t) {
+			t = n.UpdatedAt
+		}
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC()
+}
+
+// resolve resolves ref, which may be relative, against f.Link.
+func (f Feed) resolve(ref string) string {
+	base, err := url.Parse(f.Link)
+	if err != nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// name returns the display name of u.
+func name(u users.User) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Login
+}
+
+// readState returns the category for the read state of a notification.
+func readState(read bool) string {
+	if read {
+		return "read"
+	}
+	return "unread"
+}
+
+func encode(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Links    []atomLink   `xml:"link"`
+	Updated  string       `xml:"updated"`
+	Author   atomPerson   `xml:"author"`
+	Category atomCategory `xml:"category"`
+	Summary  string       `xml:"summary,omitempty"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	URI   string `xml:"uri,omitempty"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Author      string  `xml:"author,omitempty"` // Email address, as required by RSS.
+	Creator     string  `xml:"dc:creator"`
+	Category    string  `xml:"category"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
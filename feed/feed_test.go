@@ -0,0 +1,208 @@
+package feed_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/feed"
+	"github.com/shurcooL/users"
+)
+
+var testNotifications = notifications.Notifications{
+	{
+		RepoSpec:   notifications.RepoSpec{URI: "example.org/repo"},
+		ThreadType: "issues",
+		ThreadID:   1,
+		Title:      "Issue 1",
+		Actor:      users.User{Login: "gopher", Name: "Gopher", Email: "gopher@example.org", HTMLURL: "https://example.org/gopher"},
+		UpdatedAt:  time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC),
+		HTMLURL:    "/issues/1",
+		Body:       "Hello.",
+	},
+	{
+		RepoSpec:   notifications.RepoSpec{URI: "example.org/repo"},
+		ThreadType: "pulls",
+		ThreadID:   2,
+		Title:      "Pull 2",
+		Actor:      users.User{Login: "gopher2"},
+		UpdatedAt:  time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		Read:       true,
+		HTMLURL:    "https://example.org/pulls/2",
+	},
+}
+
+func TestAtom(t *testing.T) {
+	var buf bytes.Buffer
+	err := feed.Atom(&buf, feed.Feed{Title: "Notifications", Link: "https://example.org/notifications"}, testNotifications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID   string `xml:"id"`
+			Link struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Updated string `xml:"updated"`
+			Author  struct {
+				Name  string `xml:"name"`
+				Email string `xml:"email"`
+			} `xml:"author"`
+			Category struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Summary string `xml:"summary"`
+		} `xml:"entry"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if got.ID != "https://example.org/notifications" || got.Updated != "2018-01-02T00:00:00Z" {
+		t.Errorf("got feed ID %q, updated %q", got.ID, got.Updated)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got.Entries))
+	}
+	e := got.Entries[0]
+	if want := feed.EntryID(testNotifications[0].RepoSpec, "issues", 1); e.ID != want {
+		t.Errorf("got entry ID %q, want %q", e.ID, want)
+	}
+	if e.Link.Href != "https://example.org/issues/1" || e.Updated != "2018-01-02T00:00:00Z" ||
+		e.Author.Name != "Gopher" || e.Author.Email != "gopher@example.org" ||
+		e.Category.Term != "unread" || e.Summary != "Hello." {
+		t.Errorf("got unexpected entry: %+v", e)
+	}
+	if e := got.Entries[1]; e.Link.Href != "https://example.org/pulls/2" || e.Author.Name != "gopher2" || e.Category.Term != "read" {
+		t.Errorf("got unexpected entry: %+v", e)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	var buf bytes.Buffer
+	err := feed.RSS(&buf, feed.Feed{Title: "Notifications", Link: "https://example.org/notifications"}, testNotifications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Items []struct {
+			Link string `xml:"link"`
+			GUID struct {
+				IsPermaLink string `xml:"isPermaLink,attr"`
+				ID          string `xml:",chardata"`
+			} `xml:"guid"`
+			PubDate  string `xml:"pubDate"`
+			Author   string `xml:"author"`
+			Creator  string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Category string `xml:"category"`
+		} `xml:"channel>item"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(got.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(got.Items))
+	}
+	i := got.Items[0]
+	if want := feed.EntryID(testNotifications[0].RepoSpec, "issues", 1); i.GUID.ID != want || i.GUID.IsPermaLink != "false" {
+		t.Errorf("got guid %+v, want %q", i.GUID, want)
+	}
+	if i.Link != "https://example.org/issues/1" || i.PubDate != "Tue, 02 Jan 2018 00:00:00 +0000" ||
+		i.Author != "gopher@example.org (Gopher)" || i.Creator != "Gopher" || i.Category != "unread" {
+		t.Errorf("got unexpected item: %+v", i)
+	}
+	if i := got.Items[1]; i.Author != "" || i.Creator != "gopher2" || i.Category != "read" {
+		t.Errorf("got unexpected item: %+v", i)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ts := httptest.NewServer(feed.Handler{
+		Notifications: mockService{},
+		Context: func(req *http.Request, token string) (context.Context, error) {
+			if token != "secret" {
+				return nil, fmt.Errorf("invalid token")
+			}
+			return context.WithValue(req.Context(), authenticatedKey, true), nil
+		},
+		Feed: feed.Feed{Title: "Notifications", Link: "https://example.org/notifications"},
+	})
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		query           string
+		wantStatus      int
+		wantContentType string
+	}{
+		{query: "", wantStatus: http.StatusUnauthorized},
+		{query: "?token=bad", wantStatus: http.StatusUnauthorized},
+		{query: "?token=secret", wantStatus: http.StatusOK, wantContentType: "application/atom+xml; charset=utf-8"},
+		{query: "?token=secret&format=rss", wantStatus: http.StatusOK, wantContentType: "application/rss+xml; charset=utf-8"},
+	} {
+		resp, err := http.Get(ts.URL + tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body bytes.Buffer
+		_, err = body.ReadFrom(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("%q: got status %d, want %d", tc.query, resp.StatusCode, tc.wantStatus)
+			continue
+		}
+		if tc.wantStatus != http.StatusOK {
+			continue
+		}
+		if got := resp.Header.Get("Content-Type"); got != tc.wantContentType {
+			t.Errorf("%q: got Content-Type %q, want %q", tc.query, got, tc.wantContentType)
+		}
+		if !strings.Contains(body.String(), "Issue 1") {
+			t.Errorf("%q: feed doesn't contain notification:\n%s", tc.query, body.String())
+		}
+	}
+}
+
+func TestHandlerNilContext(t *testing.T) {
+	ts := httptest.NewServer(feed.Handler{Notifications: mockService{}})
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusInternalServerError; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
+
+type contextKey struct{}
+
+var authenticatedKey = contextKey{}
+
+// mockService lists testNotifications to authenticated users.
+type mockService struct {
+	notifications.Service
+}
+
+func (mockService) List(ctx context.Context, _ notifications.ListOptions) (notifications.Notifications, string, error) {
+	if ctx.Value(authenticatedKey) != true {
+		return nil, "", os.ErrPermission
+	}
+	return testNotifications, "", nil
+}
@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/shurcooL/notifications"
+)
+
+// Handler serves the notifications of the user identified by a token as a feed.
+//
+// The token is taken from the "token" query parameter, since feed readers
+// generally can't set headers. The feed is Atom, unless the "format"
+// query parameter is "rss".
+type Handler struct {
+	Notifications notifications.Service
+
+	// Context returns the context for serving req, carrying the user
+	// identified by token as the authenticated user.
+	// An error from Context results in a 401 Unauthorized response.
+	// Context is required, since only it knows how to check tokens.
+	// If nil, all requests result in a 500 Internal Server Error response.
+	Context func(req *http.Request, token string) (context.Context, error)
+
+	// Feed describes the served feed.
+	Feed Feed
+
+	// Options are the options used to list notifications.
+	Options notifications.ListOptions
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Context == nil {
+		log.Println("feed: Handler.Context is nil")
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	token := req.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "401 Unauthorized: missing token", http.StatusUnauthorized)
+		return
+	}
+	ctx, err := h.Context(req, token)
+	if err != nil {
+		http.Error(w, "401 Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	ns, _, err := h.Notifications.List(ctx, h.Options)
+	if os.IsPermission(err) {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	} else if err != nil {
+		log.Println("feed: List:", err)
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/atom+xml; charset=utf-8"
+	render := Atom
+	if req.URL.Query().Get("format") == "rss" {
+		contentType = "application/rss+xml; charset=utf-8"
+		render = RSS
+	}
+	err = render(&buf, h.Feed, ns)
+	if err != nil {
+		log.Println("feed: rendering feed:", err)
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	_, _ = buf.WriteTo(w)
+}
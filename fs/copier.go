@@ -13,7 +13,7 @@ var _ notifications.CopierFrom = &service{}
 
 func (s *service) CopyFrom(ctx context.Context, src notifications.Service, dst users.UserSpec) error {
 	// List all accessible notifications.
-	ns, err := src.List(ctx, notifications.ListOptions{})
+	ns, _, err := src.List(ctx, notifications.ListOptions{})
 	if err != nil {
 		return err
 	}
@@ -33,10 +33,20 @@ func (s *service) CopyFrom(ctx context.Context, src notifications.Service, dst u
 			ThreadID:   n.ThreadID,
 			Title:      n.Title,
 			HTMLURL:    n.HTMLURL,
+			Body:       n.Body,
 			UpdatedAt:  n.UpdatedAt,
+			Kind:       string(n.Kind),
+			State:      string(n.State),
 			Icon:       fromOcticonID(n.Icon),
 			Color:      fromRGB(n.Color),
 			Actor:      fromUserSpec(n.Actor.UserSpec),
+			Actors:     fromUsers(n.Actors),
+			EventCount: n.EventCount,
+
+			Reason:        string(n.Reason),
+			Participating: n.Participating,
+			Mentioned:     n.Mentioned,
+			Saved:         n.Saved,
 		}
 
 		// Put in storage.
@@ -46,6 +56,14 @@ func (s *service) CopyFrom(ctx context.Context, src notifications.Service, dst u
 		}
 	}
 
-	fmt.Println("All done.")
 	return nil
 }
+
+// fromUsers converts us to on-disk actors.
+func fromUsers(us []users.User) []userSpec {
+	var actors []userSpec
+	for _, u := range us {
+		actors = append(actors, fromUserSpec(u.UserSpec))
+	}
+	return actors
+}
@@ -0,0 +1,112 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+	"github.com/shurcooL/webdavfs/vfsutil"
+)
+
+// entry is a stored notification, along with its key
+// and whether it's in the read directory.
+type entry struct {
+	Key  string
+	Read bool
+	N    notification
+}
+
+// position is where a notification is in the order that List pages through.
+//
+// Notifications are ordered most recently updated first, then by key.
+// The order doesn't depend on whether a notification is read,
+// so marking notifications read while paging doesn't move them.
+type position struct {
+	UpdatedAt time.Time
+	Key       string
+}
+
+func (e entry) position() position {
+	return position{UpdatedAt: e.N.UpdatedAt, Key: e.Key}
+}
+
+// less reports whether p is ordered before other.
+func (p position) less(other position) bool {
+	if !p.UpdatedAt.Equal(other.UpdatedAt) {
+		return p.UpdatedAt.After(other.UpdatedAt)
+	}
+	return p.Key < other.Key
+}
+
+// cursor returns an opaque cursor pointing just past p.
+func (p position) cursor() string {
+	return p.UpdatedAt.UTC().Format(time.RFC3339Nano) + "/" + p.Key
+}
+
+// parseCursor parses a cursor created by position.cursor.
+func parseCursor(cursor string) (position, error) {
+	updatedAt, key, ok := strings.Cut(cursor, "/")
+	if !ok || key == "" {
+		return position{}, fmt.Errorf("%w %q", notifications.ErrInvalidCursor, cursor)
+	}
+	t, err := time.Parse(time.RFC3339Nano, updatedAt)
+	if err != nil {
+		return position{}, fmt.Errorf("%w %q", notifications.ErrInvalidCursor, cursor)
+	}
+	return position{UpdatedAt: t, Key: key}, nil
+}
+
+// listEntries returns entries of all notifications of user in the
+// notifications directory, followed by the read directory if read is true.
+// Entries are sorted in the order that List pages through.
+// s.fsMu must be held.
+func (s *service) listEntries(ctx context.Context, user users.UserSpec, read bool) ([]entry, error) {
+	es, err := s.readEntries(ctx, user, false)
+	if err != nil {
+		return nil, err
+	}
+	if read {
+		readEs, err := s.readEntries(ctx, user, true)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, readEs...)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].position().less(es[j].position()) })
+	return es, nil
+}
+
+// readEntries returns entries of all notifications of user in the
+// read directory if read is true, or the notifications directory otherwise.
+// It returns no entries if the directory doesn't exist.
+// s.fsMu must be held.
+func (s *service) readEntries(ctx context.Context, user users.UserSpec, read bool) ([]entry, error) {
+	dir, path := notificationsDir(user), notificationPath
+	if read {
+		dir, path = readDir(user), readPath
+	}
+	fis, err := vfsutil.ReadDir(ctx, s.fs, dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var es []entry
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		e := entry{Key: fi.Name(), Read: read}
+		err := jsonDecodeFile(ctx, s.fs, path(user, e.Key), &e.N)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path(user, e.Key), err)
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
@@ -0,0 +1,133 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+	"github.com/shurcooL/webdavfs/vfsutil"
+)
+
+var _ notifications.ThreadEventLister = &service{}
+
+// maxThreadEvents is the maximum number of events kept per thread.
+// Older events are dropped as new ones are appended.
+const maxThreadEvents = 100
+
+func (s *service) ListThreadEvents(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) ([]notifications.ThreadEvent, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser.ID == 0 {
+		return nil, os.ErrPermission
+	}
+
+	s.fsMu.RLock()
+	defer s.fsMu.RUnlock()
+
+	key := notificationKey(repo, threadType, threadID)
+	n, read, err := s.existing(ctx, currentUser, key)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+	seqs, err := s.eventSeqs(ctx, currentUser, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The notification counts events since the thread was last read,
+	// and those are the most recent ones.
+	unread := 0
+	if !read {
+		unread = n.eventCount()
+	}
+	var es []notifications.ThreadEvent
+	for i, seq := range seqs {
+		var e event
+		err := jsonDecodeFile(ctx, s.fs, eventPath(currentUser, key, seq), &e)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", eventPath(currentUser, key, seq), err)
+		}
+		es = append(es, notifications.ThreadEvent{
+			Title:     e.Title,
+			Actor:     s.user(ctx, e.Actor.UserSpec()),
+			CreatedAt: e.CreatedAt,
+			Read:      len(seqs)-i > unread,
+			HTMLURL:   e.HTMLURL,
+			Body:      e.Body,
+		})
+	}
+	return es, nil
+}
+
+// appendEvent appends e to the events of thread with key of user,
+// dropping the oldest events beyond maxThreadEvents.
+// s.fsMu must be held.
+func (s *service) appendEvent(ctx context.Context, user users.UserSpec, key string, e event) error {
+	seqs, err := s.eventSeqs(ctx, user, key)
+	if err != nil {
+		return err
+	}
+	var seq uint64
+	if len(seqs) > 0 {
+		seq = seqs[len(seqs)-1] + 1
+	}
+
+	// Create eventsDir for user in case it doesn't already exist.
+	err = vfsutil.MkdirAll(ctx, s.fs, eventsDir(user, key), 0755)
+	if err != nil {
+		return err
+	}
+	err = jsonEncodeFile(ctx, s.fs, eventPath(user, key, seq), e)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", eventPath(user, key, seq), err)
+	}
+
+	for len(seqs)+1 > maxThreadEvents {
+		err := s.fs.RemoveAll(ctx, eventPath(user, key, seqs[0]))
+		if err != nil {
+			return err
+		}
+		seqs = seqs[1:]
+	}
+	return nil
+}
+
+// removeEvents removes all events of thread with key of user.
+// s.fsMu must be held.
+func (s *service) removeEvents(ctx context.Context, user users.UserSpec, key string) error {
+	err := s.fs.RemoveAll(ctx, eventsDir(user, key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return removeIfEmpty(ctx, s.fs, userEventsDir(user))
+}
+
+// eventSeqs returns sequence numbers of events of thread with key of user, in order.
+// s.fsMu must be held.
+func (s *service) eventSeqs(ctx context.Context, user users.UserSpec, key string) ([]uint64, error) {
+	fis, err := vfsutil.ReadDir(ctx, s.fs, eventsDir(user, key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, fi := range fis {
+		seq, err := strconv.ParseUint(fi.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
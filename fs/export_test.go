@@ -0,0 +1,12 @@
+package fs
+
+import (
+	"time"
+
+	"github.com/shurcooL/notifications"
+)
+
+// SetNow sets the clock of s, which must be created by NewService.
+func SetNow(s notifications.Service, now func() time.Time) {
+	s.(*service).now = now
+}
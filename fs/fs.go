@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -21,6 +22,7 @@ func NewService(root webdav.FileSystem, users users.Service) notifications.Servi
 	return &service{
 		fs:    root,
 		users: users,
+		now:   time.Now,
 	}
 }
 
@@ -29,112 +31,147 @@ type service struct {
 	fs   webdav.FileSystem
 
 	users users.Service
+	now   func() time.Time // Clock, replaceable for testing.
+
+	watchersMu sync.Mutex
+	watchers   map[users.UserSpec]map[chan notifications.Change]bool // Keyed by watching user. Values report whether the watcher overflowed.
 }
 
-func (s *service) List(ctx context.Context, opt notifications.ListOptions) (notifications.Notifications, error) {
+func (s *service) List(ctx context.Context, opt notifications.ListOptions) (notifications.Notifications, string, error) {
 	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if currentUser.ID == 0 {
-		return nil, os.ErrPermission
+		return nil, "", os.ErrPermission
+	}
+
+	err = s.unsnooze(ctx, currentUser)
+	if err != nil {
+		return nil, "", err
+	}
+	if opt.All {
+		err = s.purgeRead(ctx, currentUser)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	s.fsMu.RLock()
 	defer s.fsMu.RUnlock()
 
-	var ns notifications.Notifications
-
-	fis, err := vfsutil.ReadDir(ctx, s.fs, notificationsDir(currentUser))
-	if os.IsNotExist(err) {
-		fis = nil
-	} else if err != nil {
-		return nil, err
+	// Gather entries of unread notifications, along with read ones if opt.All.
+	entries, err := s.listEntries(ctx, currentUser, opt.All)
+	if err != nil {
+		return nil, "", err
 	}
-	for _, fi := range fis {
-		var n notification
-		err := jsonDecodeFile(ctx, s.fs, notificationPath(currentUser, fi.Name()), &n)
+
+	// Skip entries up to and including the cursor, if any.
+	if opt.Cursor != "" {
+		cursor, err := parseCursor(opt.Cursor)
 		if err != nil {
-			return nil, fmt.Errorf("error reading %s: %v", notificationPath(currentUser, fi.Name()), err)
+			return nil, "", err
 		}
+		entries = entries[sort.Search(len(entries), func(i int) bool { return cursor.less(entries[i].position()) }):]
+	}
 
-		if opt.Repo != nil && n.RepoSpec.RepoSpec() != *opt.Repo {
+	var (
+		ns         notifications.Notifications
+		nextCursor string
+	)
+	for i, e := range entries {
+		if opt.PageSize > 0 && len(ns) == opt.PageSize {
+			nextCursor = entries[i-1].position().cursor()
+			break
+		}
+		if !e.N.matches(opt) {
 			continue
 		}
-
-		// TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
-		ns = append(ns, notifications.Notification{
-			RepoSpec:   n.RepoSpec.RepoSpec(),
-			ThreadType: n.ThreadType,
-			ThreadID:   n.ThreadID,
-			Title:      n.Title,
-			Icon:       n.Icon.OcticonID(),
-			Color:      n.Color.RGB(),
-			Actor:      s.user(ctx, n.Actor.UserSpec()),
-			UpdatedAt:  n.UpdatedAt,
-			HTMLURL:    n.HTMLURL,
-		})
+		ns = append(ns, s.notification(ctx, e.N, e.Read))
 	}
+	return ns, nextCursor, nil
+}
 
-	if opt.All {
-		fis, err := vfsutil.ReadDir(ctx, s.fs, readDir(currentUser))
-		if os.IsNotExist(err) {
-			fis = nil
-		} else if err != nil {
-			return nil, err
-		}
-		for _, fi := range fis {
-			var n notification
-			err := jsonDecodeFile(ctx, s.fs, readPath(currentUser, fi.Name()), &n)
-			if err != nil {
-				return nil, fmt.Errorf("error reading %s: %v", readPath(currentUser, fi.Name()), err)
-			}
-
-			// Delete and skip old read notifications.
-			if time.Since(n.UpdatedAt) > 30*24*time.Hour {
-				err := s.fs.RemoveAll(ctx, readPath(currentUser, fi.Name()))
-				if err != nil {
-					return nil, err
-				}
-				continue
-			}
+// purgeRead deletes read notifications of user older than 30 days, unless saved.
+func (s *service) purgeRead(ctx context.Context, user users.UserSpec) error {
+	// Usually nothing is old enough, so check for that with only a read lock held.
+	s.fsMu.RLock()
+	expired, err := s.expiredRead(ctx, user)
+	s.fsMu.RUnlock()
+	if err != nil || len(expired) == 0 {
+		return err
+	}
 
-			if opt.Repo != nil && n.RepoSpec.RepoSpec() != *opt.Repo {
-				continue
-			}
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
 
-			// TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
-			ns = append(ns, notifications.Notification{
-				RepoSpec:   n.RepoSpec.RepoSpec(),
-				ThreadType: n.ThreadType,
-				ThreadID:   n.ThreadID,
-				Title:      n.Title,
-				Icon:       n.Icon.OcticonID(),
-				Color:      n.Color.RGB(),
-				Actor:      s.user(ctx, n.Actor.UserSpec()),
-				UpdatedAt:  n.UpdatedAt,
-				Read:       true,
-				HTMLURL:    n.HTMLURL,
-			})
+	// Check again, since others may have purged or changed them in the meantime.
+	expired, err = s.expiredRead(ctx, user)
+	if err != nil {
+		return err
+	}
+	for _, e := range expired {
+		err := s.fs.RemoveAll(ctx, readPath(user, e.Key))
+		if err != nil {
+			return err
+		}
+		err = s.removeEvents(ctx, user, e.Key)
+		if err != nil {
+			return err
 		}
+		s.emit(user, notifications.Change{Op: notifications.ChangeDeleted, RepoSpec: e.N.RepoSpec.RepoSpec(), ThreadType: e.N.ThreadType, ThreadID: e.N.ThreadID})
+	}
 
-		// THINK: Consider using the dir-less vfs abstraction for doing this implicitly? Less code here.
-		// If the user has no more read notifications left, remove the empty directory.
-		switch notifications, err := vfsutil.ReadDir(ctx, s.fs, readDir(currentUser)); {
-		case err != nil && !os.IsNotExist(err):
-			return nil, err
-		case err == nil && len(notifications) == 0:
-			err := s.fs.RemoveAll(ctx, readDir(currentUser))
-			if err != nil {
-				return nil, err
-			}
+	// THINK: Consider using the dir-less vfs abstraction for doing this implicitly? Less code here.
+	// If the user has no more read notifications left, remove the empty directory.
+	return removeIfEmpty(ctx, s.fs, readDir(user))
+}
+
+// expiredRead returns entries of read notifications of user
+// that are older than 30 days and not saved.
+// s.fsMu must be held.
+func (s *service) expiredRead(ctx context.Context, user users.UserSpec) ([]entry, error) {
+	es, err := s.readEntries(ctx, user, true)
+	if err != nil {
+		return nil, err
+	}
+	var expired []entry
+	for _, e := range es {
+		if !e.N.Saved && s.now().Sub(e.N.UpdatedAt) > 30*24*time.Hour {
+			expired = append(expired, e)
 		}
 	}
+	return expired, nil
+}
 
-	return ns, nil
+// notification converts on-disk notification n to a notifications.Notification.
+func (s *service) notification(ctx context.Context, n notification, read bool) notifications.Notification {
+	// TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
+	return notifications.Notification{
+		RepoSpec:   n.RepoSpec.RepoSpec(),
+		ThreadType: n.ThreadType,
+		ThreadID:   n.ThreadID,
+		Title:      n.Title,
+		Kind:       n.kind(),
+		State:      notifications.SubjectState(n.State),
+		Icon:       notifications.OcticonID(notifications.Octicons.Icon(n.kind(), string(n.Icon))),
+		Color:      n.Color.RGB(),
+		Actor:      s.user(ctx, n.Actor.UserSpec()),
+		Actors:     s.actors(ctx, n.actors()),
+		EventCount: n.eventCount(),
+		UpdatedAt:  n.UpdatedAt,
+		Read:       read,
+		HTMLURL:    n.HTMLURL,
+		Body:       n.Body,
+
+		Reason:        n.reason(),
+		Participating: n.Participating,
+		Mentioned:     n.Mentioned,
+		Saved:         n.Saved,
+	}
 }
 
-func (s *service) Count(ctx context.Context, opt interface{}) (uint64, error) {
+func (s *service) Count(ctx context.Context, opt notifications.CountOptions) (uint64, error) {
 	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
 	if err != nil {
 		return 0, err
@@ -143,17 +180,44 @@ func (s *service) Count(ctx context.Context, opt interface{}) (uint64, error) {
 		return 0, os.ErrPermission
 	}
 
+	err = s.unsnooze(ctx, currentUser)
+	if err != nil {
+		return 0, err
+	}
+
 	s.fsMu.RLock()
 	defer s.fsMu.RUnlock()
 
 	// TODO: Consider reading/parsing entries, in case there's .DS_Store, etc., that should be skipped?
-	notifications, err := vfsutil.ReadDir(ctx, s.fs, notificationsDir(currentUser))
+	fis, err := vfsutil.ReadDir(ctx, s.fs, notificationsDir(currentUser))
 	if os.IsNotExist(err) {
-		notifications = nil
+		fis = nil
 	} else if err != nil {
 		return 0, err
 	}
-	return uint64(len(notifications)), nil
+	if opt == (notifications.CountOptions{}) {
+		// No filters, so there's no need to decode notifications.
+		return uint64(len(fis)), nil
+	}
+	filter := notifications.ListOptions{
+		Repo:          opt.Repo,
+		ThreadType:    opt.ThreadType,
+		Participating: opt.Participating,
+		Mentioned:     opt.Mentioned,
+	}
+	var count uint64
+	for _, fi := range fis {
+		var n notification
+		err := jsonDecodeFile(ctx, s.fs, notificationPath(currentUser, fi.Name()), &n)
+		if err != nil {
+			return 0, fmt.Errorf("error reading %s: %v", notificationPath(currentUser, fi.Name()), err)
+		}
+		if !n.matches(filter) {
+			continue
+		}
+		count++
+	}
+	return count, nil
 }
 
 func (s *service) Notify(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, nr notifications.NotificationRequest) error {
@@ -168,52 +232,43 @@ func (s *service) Notify(ctx context.Context, repo notifications.RepoSpec, threa
 	s.fsMu.Lock()
 	defer s.fsMu.Unlock()
 
-	type subscription struct {
-		Participating bool
+	subscribers, err := s.subscribers(ctx, repo, threadType, threadID)
+	if err != nil {
+		return err
 	}
-	var subscribers = make(map[users.UserSpec]subscription)
 
-	// Repo watchers.
-	fis, err := vfsutil.ReadDir(ctx, s.fs, subscribersDir(repo, "", 0))
-	if os.IsNotExist(err) {
-		fis = nil
-	} else if err != nil {
-		return err
+	var mentioned = make(map[users.UserSpec]bool)
+	for _, user := range nr.Mentions {
+		mentioned[user] = true
 	}
-	for _, fi := range fis {
-		if fi.IsDir() {
-			continue
-		}
-		subscriber, err := unmarshalUserSpec(fi.Name())
-		if err != nil {
+
+	for subscriber, participating := range subscribers {
+		if currentUser.ID != 0 && subscriber == currentUser {
+			// Don't notify user of his own actions.
 			continue
 		}
-		subscribers[subscriber] = subscription{Participating: false}
-	}
 
-	// Thread subscribers. Iterate over them after repo watchers,
-	// so that their participating status takes higher precedence.
-	fis, err = vfsutil.ReadDir(ctx, s.fs, subscribersDir(repo, threadType, threadID))
-	if os.IsNotExist(err) {
-		fis = nil
-	} else if err != nil {
-		return err
-	}
-	for _, fi := range fis {
-		if fi.IsDir() {
-			continue
+		reason := nr.Reason
+		switch {
+		case mentioned[subscriber]:
+			reason = notifications.ReasonMention
+		case !participating:
+			reason = notifications.ReasonSubscribed
 		}
-		subscriber, err := unmarshalUserSpec(fi.Name())
+
+		// Skip subscribers who prefer not to get this notification in their inbox.
+		prefs, err := s.preferences(ctx, subscriber)
 		if err != nil {
+			return err
+		}
+		if !prefs.Preferences().For(repo).Wants(notifications.ChannelInbox, reason, threadType) {
 			continue
 		}
-		subscribers[subscriber] = subscription{Participating: true}
-	}
 
-	for subscriber, subscription := range subscribers {
-		if currentUser.ID != 0 && subscriber == currentUser {
-			// Don't notify user of his own actions.
-			continue
+		// Read existing notification with same key, if any, to preserve some of its fields.
+		prev, prevRead, err := s.existing(ctx, subscriber, notificationKey(repo, threadType, threadID))
+		if err != nil {
+			return err
 		}
 
 		// Delete read notification with same key, if any.
@@ -222,12 +277,42 @@ func (s *service) Notify(ctx context.Context, repo notifications.RepoSpec, threa
 			return err
 		}
 
+		// End snooze of notification with same key early, if any.
+		if _, err := vfsutil.Stat(ctx, s.fs, snoozedPath(subscriber, notificationKey(repo, threadType, threadID))); err == nil {
+			err := s.fs.RemoveAll(ctx, snoozedPath(subscriber, notificationKey(repo, threadType, threadID)))
+			if err != nil {
+				return err
+			}
+			err = removeIfEmpty(ctx, s.fs, snoozedDir(subscriber))
+			if err != nil {
+				return err
+			}
+		}
+
 		// Create notificationsDir for subscriber in case it doesn't already exist.
 		err = s.fs.Mkdir(ctx, notificationsDir(subscriber), 0755)
 		if err != nil && !os.IsExist(err) {
 			return err
 		}
 
+		// An existing unread notification with same key, if any, is updated rather than created.
+		op := notifications.ChangeCreated
+		if _, err := vfsutil.Stat(ctx, s.fs, notificationPath(subscriber, notificationKey(repo, threadType, threadID))); err == nil {
+			op = notifications.ChangeUpdated
+		}
+
+		// Events since the thread was last read accumulate, most recent actor first.
+		actors, eventCount := []userSpec{fromUserSpec(nr.Actor)}, 1
+		if prev != nil && !prevRead {
+			for _, a := range prev.actors() {
+				if a.Equal(nr.Actor) {
+					continue
+				}
+				actors = append(actors, a)
+			}
+			eventCount += prev.eventCount()
+		}
+
 		// TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
 		n := notification{
 			RepoSpec:   fromRepoSpec(repo),
@@ -235,24 +320,150 @@ func (s *service) Notify(ctx context.Context, repo notifications.RepoSpec, threa
 			ThreadID:   threadID,
 			Title:      nr.Title,
 			HTMLURL:    nr.HTMLURL,
+			Body:       nr.Body,
 			UpdatedAt:  nr.UpdatedAt,
+			ReceivedAt: s.now(),
+			Kind:       string(nr.Kind),
+			State:      string(nr.State),
 			Icon:       fromOcticonID(nr.Icon),
 			Color:      fromRGB(nr.Color),
 			Actor:      fromUserSpec(nr.Actor), // TODO: Why not use current user?
+			Actors:     actors,
+			EventCount: eventCount,
 
-			Participating: subscription.Participating,
+			Reason:        string(reason),
+			Participating: participating,
+			Mentioned:     mentioned[subscriber],
+			Saved:         prev != nil && prev.Saved,
 		}
 		err = jsonEncodeFile(ctx, s.fs, notificationPath(subscriber, notificationKey(repo, threadType, threadID)), n)
-		// TODO: Maybe in future read previous value, and use it to preserve some fields, like earliest HTML URL.
+		// TODO: Maybe preserve more fields of previous value, like earliest HTML URL.
 		//       Maybe that shouldn't happen here though.
 		if err != nil {
 			return fmt.Errorf("error writing %s: %v", notificationPath(subscriber, notificationKey(repo, threadType, threadID)), err)
 		}
+		err = s.appendEvent(ctx, subscriber, notificationKey(repo, threadType, threadID), event{
+			Title:     nr.Title,
+			Actor:     fromUserSpec(nr.Actor),
+			CreatedAt: nr.UpdatedAt,
+			HTMLURL:   nr.HTMLURL,
+			Body:      nr.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		if s.watched(subscriber) {
+			changed := s.notification(ctx, n, false)
+			s.emit(subscriber, notifications.Change{
+				Op:           op,
+				RepoSpec:     repo,
+				ThreadType:   threadType,
+				ThreadID:     threadID,
+				Notification: &changed,
+			})
+		}
 	}
 
 	return nil
 }
 
+// existing returns the notification of user with key, wherever it's stored,
+// and whether it's read. It returns nil if there's no such notification.
+// s.fsMu must be held.
+func (s *service) existing(ctx context.Context, user users.UserSpec, key string) (_ *notification, read bool, _ error) {
+	for _, e := range []struct {
+		path string
+		read bool
+	}{
+		{notificationPath(user, key), false},
+		{readPath(user, key), true},
+		{snoozedPath(user, key), false},
+	} {
+		var n notification
+		err := jsonDecodeFile(ctx, s.fs, e.path, &n)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, false, fmt.Errorf("error reading %s: %v", e.path, err)
+		}
+		return &n, e.read, nil
+	}
+	return nil, false, nil
+}
+
+// subscribers returns users to notify of the specified thread,
+// and whether each one is participating in it, or just watching the repo.
+// Repo watchers are included according to their watch level.
+// Users who ignore the thread, or the entire repo, are left out.
+// If threadType and threadID are zero, only repo watchers are returned.
+// s.fsMu must be held.
+func (s *service) subscribers(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) (map[users.UserSpec]bool, error) {
+	var subscribers = make(map[users.UserSpec]bool) // Value is whether participating.
+
+	// Repo watchers.
+	var ignoring = make(map[users.UserSpec]bool) // Users ignoring the entire repo.
+	fis, err := vfsutil.ReadDir(ctx, s.fs, subscribersDir(repo, "", 0))
+	if os.IsNotExist(err) {
+		fis = nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		subscriber, err := unmarshalUserSpec(fi.Name())
+		if err != nil {
+			continue
+		}
+		sub, err := readSubscription(ctx, s.fs, subscriberPath(repo, "", 0, subscriber))
+		if err != nil {
+			return nil, err
+		}
+		switch w := sub.watch(); {
+		case w.Level == notifications.WatchIgnore:
+			ignoring[subscriber] = true
+		case w.Watching(threadType),
+			threadType == "" && threadID == 0 && w.Level == notifications.WatchCustom:
+			subscribers[subscriber] = false
+		}
+	}
+
+	if threadType == "" && threadID == 0 {
+		return subscribers, nil
+	}
+
+	// Thread subscribers. Iterate over them after repo watchers,
+	// so that their participating or ignored status takes higher precedence.
+	fis, err = vfsutil.ReadDir(ctx, s.fs, subscribersDir(repo, threadType, threadID))
+	if os.IsNotExist(err) {
+		fis = nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		subscriber, err := unmarshalUserSpec(fi.Name())
+		if err != nil {
+			continue
+		}
+		sub, err := readSubscription(ctx, s.fs, subscriberPath(repo, threadType, threadID, subscriber))
+		if err != nil {
+			return nil, err
+		}
+		if sub.Ignored || ignoring[subscriber] {
+			delete(subscribers, subscriber)
+			continue
+		}
+		subscribers[subscriber] = true
+	}
+
+	return subscribers, nil
+}
+
 func (s *service) Subscribe(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
 	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
 	if err != nil {
@@ -265,17 +476,21 @@ func (s *service) Subscribe(ctx context.Context, repo notifications.RepoSpec, th
 	s.fsMu.Lock()
 	defer s.fsMu.Unlock()
 
+	err = vfsutil.MkdirAll(ctx, s.fs, subscribersDir(repo, threadType, threadID), 0755)
+	if err != nil {
+		return err
+	}
 	for _, subscriber := range subscribers {
-		err := createEmptyFile(ctx, s.fs, subscriberPath(repo, threadType, threadID, subscriber))
+		err := jsonEncodeFile(ctx, s.fs, subscriberPath(repo, threadType, threadID, subscriber), newSubscription(repo, threadType, threadID))
 		if err != nil {
-			return err
+			return fmt.Errorf("error writing %s: %v", subscriberPath(repo, threadType, threadID, subscriber), err)
 		}
 	}
 
 	return nil
 }
 
-func (s *service) MarkRead(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+func (s *service) Unsubscribe(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
 	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
 	if err != nil {
 		return err
@@ -287,23 +502,88 @@ func (s *service) MarkRead(ctx context.Context, repo notifications.RepoSpec, thr
 	s.fsMu.Lock()
 	defer s.fsMu.Unlock()
 
-	// Return early if the notification doesn't exist, before creating readDir for currentUser.
-	key := notificationKey(repo, threadType, threadID)
-	_, err = vfsutil.Stat(ctx, s.fs, notificationPath(currentUser, key))
-	if os.IsNotExist(err) {
-		return nil
+	for _, subscriber := range subscribers {
+		err := s.fs.RemoveAll(ctx, subscriberPath(repo, threadType, threadID, subscriber))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
 
-	// Create readDir for currentUser in case it doesn't already exist.
-	err = s.fs.Mkdir(ctx, readDir(currentUser), 0755)
-	if err != nil && !os.IsExist(err) {
+	// If there are no more subscribers left, remove the empty directory.
+	return removeIfEmpty(ctx, s.fs, subscribersDir(repo, threadType, threadID))
+}
+
+func (s *service) Ignore(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
 		return err
 	}
-	// Move notification to read directory.
-	err = s.fs.Rename(ctx, notificationPath(currentUser, key), readPath(currentUser, key))
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+	if threadType == "" && threadID == 0 {
+		return fmt.Errorf("threadType and threadID must be non-zero")
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	err = vfsutil.MkdirAll(ctx, s.fs, subscribersDir(repo, threadType, threadID), 0755)
 	if err != nil {
 		return err
 	}
+	sub := newSubscription(repo, threadType, threadID)
+	sub.Ignored = true
+	for _, subscriber := range subscribers {
+		err := jsonEncodeFile(ctx, s.fs, subscriberPath(repo, threadType, threadID, subscriber), sub)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", subscriberPath(repo, threadType, threadID, subscriber), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) MarkRead(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	return s.MarkReadBatch(ctx, []notifications.ThreadRef{{RepoSpec: repo, ThreadType: threadType, ThreadID: threadID}})
+}
+
+func (s *service) MarkReadBatch(ctx context.Context, threads []notifications.ThreadRef) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	madeReadDir := false
+	for _, t := range threads {
+		// Skip the notification if it doesn't exist, before creating readDir for currentUser.
+		key := notificationKey(t.RepoSpec, t.ThreadType, t.ThreadID)
+		_, err = vfsutil.Stat(ctx, s.fs, notificationPath(currentUser, key))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		// Create readDir for currentUser in case it doesn't already exist.
+		if !madeReadDir {
+			err = s.fs.Mkdir(ctx, readDir(currentUser), 0755)
+			if err != nil && !os.IsExist(err) {
+				return err
+			}
+			madeReadDir = true
+		}
+		// Move notification to read directory.
+		err = s.fs.Rename(ctx, notificationPath(currentUser, key), readPath(currentUser, key))
+		if err != nil {
+			return err
+		}
+		s.emit(currentUser, notifications.Change{Op: notifications.ChangeRead, RepoSpec: t.RepoSpec, ThreadType: t.ThreadType, ThreadID: t.ThreadID})
+	}
 
 	// THINK: Consider using the dir-less vfs abstraction for doing this implicitly? Less code here.
 	// If the user has no more unread notifications left, remove the empty directory.
@@ -320,7 +600,7 @@ func (s *service) MarkRead(ctx context.Context, repo notifications.RepoSpec, thr
 	return nil
 }
 
-func (s *service) MarkAllRead(ctx context.Context, repo notifications.RepoSpec) error {
+func (s *service) MarkAllRead(ctx context.Context, opt notifications.MarkAllReadOptions) error {
 	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
 	if err != nil {
 		return err
@@ -349,7 +629,12 @@ func (s *service) MarkAllRead(ctx context.Context, repo notifications.RepoSpec)
 		}
 
 		// Skip notifications whose repo doesn't match.
-		if n.RepoSpec.RepoSpec() != repo {
+		if opt.Repo != nil && n.RepoSpec.RepoSpec() != *opt.Repo {
+			continue
+		}
+		// Skip notifications that arrived after the user last saw them.
+		// Arrival time is used rather than UpdatedAt, which may predate arrival.
+		if !opt.LastReadAt.IsZero() && n.receivedAt().After(opt.LastReadAt) {
 			continue
 		}
 
@@ -362,11 +647,11 @@ func (s *service) MarkAllRead(ctx context.Context, repo notifications.RepoSpec)
 			madeReadDir = true
 		}
 		// Move notification to read directory.
-		key := notificationKey(repo, n.ThreadType, n.ThreadID)
-		err = s.fs.Rename(ctx, notificationPath(currentUser, key), readPath(currentUser, key))
+		err = s.fs.Rename(ctx, notificationPath(currentUser, fi.Name()), readPath(currentUser, fi.Name()))
 		if err != nil {
 			return err
 		}
+		s.emit(currentUser, notifications.Change{Op: notifications.ChangeRead, RepoSpec: n.RepoSpec.RepoSpec(), ThreadType: n.ThreadType, ThreadID: n.ThreadID})
 	}
 
 	// THINK: Consider using the dir-less vfs abstraction for doing this implicitly? Less code here.
@@ -384,6 +669,88 @@ func (s *service) MarkAllRead(ctx context.Context, repo notifications.RepoSpec)
 	return nil
 }
 
+func (s *service) MarkUnread(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	// Return early if the read notification doesn't exist, before creating notificationsDir for currentUser.
+	key := notificationKey(repo, threadType, threadID)
+	_, err = vfsutil.Stat(ctx, s.fs, readPath(currentUser, key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	// Create notificationsDir for currentUser in case it doesn't already exist.
+	err = s.fs.Mkdir(ctx, notificationsDir(currentUser), 0755)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+	// Move notification back to notifications directory.
+	err = s.fs.Rename(ctx, readPath(currentUser, key), notificationPath(currentUser, key))
+	if err != nil {
+		return err
+	}
+	s.emit(currentUser, notifications.Change{Op: notifications.ChangeUnread, RepoSpec: repo, ThreadType: threadType, ThreadID: threadID})
+
+	// If the user has no more read notifications left, remove the empty directory.
+	return removeIfEmpty(ctx, s.fs, readDir(currentUser))
+}
+
+func (s *service) Delete(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	// The notification may be unread, read, or snoozed.
+	key := notificationKey(repo, threadType, threadID)
+	deleted := false
+	for _, path := range []string{notificationPath(currentUser, key), readPath(currentUser, key), snoozedPath(currentUser, key)} {
+		_, err := vfsutil.Stat(ctx, s.fs, path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = s.fs.RemoveAll(ctx, path)
+		if err != nil {
+			return err
+		}
+		deleted = true
+	}
+	if !deleted {
+		return nil
+	}
+	err = s.removeEvents(ctx, currentUser, key)
+	if err != nil {
+		return err
+	}
+	s.emit(currentUser, notifications.Change{Op: notifications.ChangeDeleted, RepoSpec: repo, ThreadType: threadType, ThreadID: threadID})
+
+	// If the user has no more notifications left, remove the empty directories.
+	for _, dir := range []string{notificationsDir(currentUser), readDir(currentUser), snoozedDir(currentUser)} {
+		err := removeIfEmpty(ctx, s.fs, dir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *service) user(ctx context.Context, user users.UserSpec) users.User {
 	u, err := s.users.Get(ctx, user)
 	if err != nil {
@@ -396,3 +763,12 @@ func (s *service) user(ctx context.Context, user users.UserSpec) users.User {
 	}
 	return u
 }
+
+// actors returns users of actors.
+func (s *service) actors(ctx context.Context, actors []userSpec) []users.User {
+	us := make([]users.User, 0, len(actors))
+	for _, a := range actors {
+		us = append(us, s.user(ctx, a.UserSpec()))
+	}
+	return us
+}
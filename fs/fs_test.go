@@ -2,7 +2,11 @@ package fs_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -26,7 +30,7 @@ func Test(t *testing.T) {
 	s := fs.NewService(mem, usersService)
 
 	// List notifications.
-	ns, err := s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,7 +69,7 @@ func Test(t *testing.T) {
 	usersService.Current.ID = 1
 
 	// List notifications.
-	ns, err = s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -80,14 +84,14 @@ func Test(t *testing.T) {
 	}
 
 	// List notifications.
-	ns, err = s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(ns) != 0 {
 		t.Errorf("want no notifications, got: %+v", ns)
 	}
-	ns, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -118,14 +122,14 @@ func Test(t *testing.T) {
 	usersService.Current.ID = 1
 
 	// List notifications.
-	ns, err = s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(ns) != 2 {
 		t.Errorf("want 2 notifications, got: %+v", ns)
 	}
-	ns, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -134,20 +138,20 @@ func Test(t *testing.T) {
 	}
 
 	// Mark all read.
-	err = s.MarkAllRead(context.Background(), notifications.RepoSpec{URI: "repo"})
+	err = s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{Repo: &notifications.RepoSpec{URI: "repo"}})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// List notifications.
-	ns, err = s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(ns) != 0 {
 		t.Errorf("want no notifications, got: %+v", ns)
 	}
-	ns, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -169,14 +173,14 @@ func Test(t *testing.T) {
 	usersService.Current.ID = 1
 
 	// List notifications.
-	ns, err = s.List(context.Background(), notifications.ListOptions{})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(ns) != 1 || ns[0].Read || ns[0].Title != "Issue 1" {
 		t.Errorf(`want 1 unread notification "Issue 1", got: %+v`, ns)
 	}
-	ns, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -185,6 +189,1147 @@ func Test(t *testing.T) {
 	}
 }
 
+func TestListPagination(t *testing.T) {
+	s, us := newTestService(t)
+	t0 := time.Now().Add(-time.Hour)
+
+	// Subscribe target user to 5 issues, and make a notification for each as another user.
+	// Issues 5 and 6 were updated at the same time.
+	for id := uint64(1); id <= 6; id++ {
+		updatedAt := t0.Add(time.Duration(id) * time.Minute)
+		if id == 6 {
+			updatedAt = t0.Add(5 * time.Minute)
+		}
+		subscribe(t, s, notifications.RepoSpec{URI: "repo"}, "issues", id, user(1))
+		notify(t, s, us, 2, notifications.RepoSpec{URI: "repo"}, "issues", id,
+			notifications.NotificationRequest{Title: fmt.Sprintf("Issue %d", id), UpdatedAt: updatedAt})
+	}
+
+	// Mark one read. Read notifications are ordered among unread ones.
+	err := s.MarkRead(context.Background(), notifications.RepoSpec{URI: "repo"}, "issues", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// List all notifications, 2 at a time.
+	var (
+		titles []string
+		pages  int
+		cursor string
+	)
+	for {
+		ns, nextCursor, err := s.List(context.Background(), notifications.ListOptions{All: true, PageSize: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ns) > 2 {
+			t.Errorf("want at most 2 notifications per page, got %d: %+v", len(ns), ns)
+		}
+		for _, n := range ns {
+			titles = append(titles, n.Title)
+		}
+		pages++
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+	if got, want := fmt.Sprint(titles), "[Issue 5 Issue 6 Issue 4 Issue 3 Issue 2 Issue 1]"; got != want {
+		t.Errorf("got titles %v, want %v", got, want)
+	}
+	if got, want := pages, 3; got != want {
+		t.Errorf("got %d pages, want %d", got, want)
+	}
+
+	// Invalid cursor.
+	_, _, err = s.List(context.Background(), notifications.ListOptions{PageSize: 2, Cursor: "bogus"})
+	if !errors.Is(err, notifications.ErrInvalidCursor) {
+		t.Errorf("invalid cursor: got error %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestListPaginationMarkRead(t *testing.T) {
+	for _, all := range []bool{false, true} {
+		s, us := newTestService(t)
+		repo := notifications.RepoSpec{URI: "repo"}
+		t0 := time.Now().Add(-time.Hour)
+		subscribe(t, s, repo, "", 0, user(1))
+		for id := uint64(1); id <= 5; id++ {
+			notify(t, s, us, 2, repo, "issues", id, notifications.NotificationRequest{
+				Title:     fmt.Sprintf("Issue %d", id),
+				UpdatedAt: t0.Add(time.Duration(id) * time.Minute),
+			})
+		}
+
+		var (
+			titles []string
+			cursor string
+		)
+		for {
+			ns, nextCursor, err := s.List(context.Background(), notifications.ListOptions{All: all, PageSize: 2, Cursor: cursor})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range ns {
+				titles = append(titles, n.Title)
+			}
+			if nextCursor == "" {
+				break
+			}
+			cursor = nextCursor
+
+			// Mark read the threads seen so far, and one that isn't seen yet.
+			for _, n := range ns {
+				err := s.MarkRead(context.Background(), repo, n.ThreadType, n.ThreadID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = s.MarkRead(context.Background(), repo, "issues", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Marking read doesn't move threads, so none are listed twice or skipped.
+		// Only the unread view drops threads marked read before their page is listed.
+		want := "[Issue 5 Issue 4 Issue 3 Issue 2 Issue 1]"
+		if !all {
+			want = "[Issue 5 Issue 4 Issue 3 Issue 1]"
+		}
+		if got := fmt.Sprint(titles); got != want {
+			t.Errorf("All %v: got titles %v, want %v", all, got, want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	s, us := newTestService(t)
+
+	// Target user watches repo "a", and participates in issue 1 of repo "b".
+	subscribe(t, s, notifications.RepoSpec{URI: "a"}, "", 0, user(1))
+	subscribe(t, s, notifications.RepoSpec{URI: "b"}, "issues", 1, user(1))
+
+	// Make notifications as another user.
+	for _, n := range []struct {
+		repo       string
+		threadType string
+		threadID   uint64
+		mentions   []users.UserSpec
+	}{
+		{repo: "a", threadType: "issues", threadID: 1},
+		{repo: "a", threadType: "changes", threadID: 1, mentions: []users.UserSpec{user(1)}},
+		{repo: "b", threadType: "issues", threadID: 1},
+	} {
+		notify(t, s, us, 2, notifications.RepoSpec{URI: n.repo}, n.threadType, n.threadID,
+			notifications.NotificationRequest{
+				Title:    "Title",
+				Mentions: n.mentions,
+				Reason:   notifications.ReasonComment,
+			})
+	}
+
+	for _, tc := range []struct {
+		opt  notifications.CountOptions
+		want uint64
+	}{
+		{opt: notifications.CountOptions{}, want: 3},
+		{opt: notifications.CountOptions{Repo: &notifications.RepoSpec{URI: "a"}}, want: 2},
+		{opt: notifications.CountOptions{ThreadType: "issues"}, want: 2},
+		{opt: notifications.CountOptions{Participating: true}, want: 1},
+		{opt: notifications.CountOptions{Mentioned: true}, want: 1},
+		{opt: notifications.CountOptions{Repo: &notifications.RepoSpec{URI: "b"}, Mentioned: true}, want: 0},
+	} {
+		got, err := s.Count(context.Background(), tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("Count(%+v): got %d, want %d", tc.opt, got, tc.want)
+		}
+	}
+
+	// Check reasons.
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := make(map[string]notifications.Reason)
+	for _, n := range ns {
+		reasons[fmt.Sprintf("%s/%s/%d", n.RepoSpec.URI, n.ThreadType, n.ThreadID)] = n.Reason
+	}
+	want := map[string]notifications.Reason{
+		"a/issues/1":  notifications.ReasonSubscribed,
+		"a/changes/1": notifications.ReasonMention,
+		"b/issues/1":  notifications.ReasonComment,
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("got reasons %v, want %v", reasons, want)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+	user2 := user(2)
+	t0 := time.Now().Add(-time.Hour)
+
+	// Target user watches the repo, and participates in issue 2.
+	subscribe(t, s, repo, "", 0, user(1))
+	subscribe(t, s, repo, "issues", 2, user(1))
+
+	for _, n := range []struct {
+		threadType string
+		threadID   uint64
+		actorID    uint64
+		updatedAt  time.Time
+		mentions   []users.UserSpec
+		reason     notifications.Reason
+	}{
+		{threadType: "issues", threadID: 1, actorID: 2, updatedAt: t0},
+		{threadType: "issues", threadID: 2, actorID: 3, updatedAt: t0.Add(time.Minute), reason: notifications.ReasonAssign},
+		{threadType: "changes", threadID: 1, actorID: 2, updatedAt: t0.Add(2 * time.Minute), mentions: []users.UserSpec{user(1)}},
+	} {
+		notify(t, s, us, n.actorID, repo, n.threadType, n.threadID,
+			notifications.NotificationRequest{
+				Title:     fmt.Sprintf("%s %d", n.threadType, n.threadID),
+				UpdatedAt: n.updatedAt,
+				Mentions:  n.mentions,
+				Reason:    n.reason,
+			})
+	}
+
+	for _, tc := range []struct {
+		name string
+		opt  notifications.ListOptions
+		want string
+	}{
+		{"none", notifications.ListOptions{}, "[changes 1 issues 2 issues 1]"},
+		{"since", notifications.ListOptions{Since: t0}, "[changes 1 issues 2]"},
+		{"before", notifications.ListOptions{Before: t0.Add(2 * time.Minute)}, "[issues 2 issues 1]"},
+		{"thread type", notifications.ListOptions{ThreadType: "issues"}, "[issues 2 issues 1]"},
+		{"reason", notifications.ListOptions{Reason: notifications.ReasonAssign}, "[issues 2]"},
+		{"participating", notifications.ListOptions{Participating: true}, "[issues 2]"},
+		{"mentioned", notifications.ListOptions{Mentioned: true}, "[changes 1]"},
+		{"actor", notifications.ListOptions{Actor: &user2}, "[changes 1 issues 1]"},
+		{"combined", notifications.ListOptions{ThreadType: "issues", Actor: &user2}, "[issues 1]"},
+	} {
+		ns, _, err := s.List(context.Background(), tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, n := range ns {
+			titles = append(titles, n.Title)
+		}
+		if got := fmt.Sprint(titles); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMarkReadBatch(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+	subscribe(t, s, repo, "", 0, user(1))
+	for id := uint64(1); id <= 3; id++ {
+		notify(t, s, us, 2, repo, "issues", id, notifications.NotificationRequest{Title: fmt.Sprintf("Issue %d", id)})
+	}
+
+	// Mark 2 of them read, along with one that doesn't exist.
+	err := s.MarkReadBatch(context.Background(), []notifications.ThreadRef{
+		{RepoSpec: repo, ThreadType: "issues", ThreadID: 1},
+		{RepoSpec: repo, ThreadType: "issues", ThreadID: 3},
+		{RepoSpec: repo, ThreadType: "issues", ThreadID: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Title != "Issue 2" {
+		t.Errorf(`want 1 unread notification "Issue 2", got: %+v`, ns)
+	}
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 3 {
+		t.Errorf("want 3 notifications, got %d: %+v", len(ns), ns)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s, us := newTestService(t)
+
+	subscribe(t, s, notifications.RepoSpec{URI: "repo"}, "issues", 1, user(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := s.(notifications.Watcher).Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make a notification as another user, twice, then mark it read.
+	for i := 0; i < 2; i++ {
+		notify(t, s, us, 2, notifications.RepoSpec{URI: "repo"}, "issues", 1, notifications.NotificationRequest{Title: "Issue 1"})
+	}
+	err = s.MarkRead(context.Background(), notifications.RepoSpec{URI: "repo"}, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []notifications.ChangeOp{notifications.ChangeCreated, notifications.ChangeUpdated, notifications.ChangeRead} {
+		c := receive(t, changes)
+		if c.Op != want || c.RepoSpec.URI != "repo" || c.ThreadType != "issues" || c.ThreadID != 1 {
+			t.Errorf("got change %+v, want %q of repo issues 1", c, want)
+		}
+		if got, want := c.Notification != nil, want != notifications.ChangeRead; got != want {
+			t.Errorf("got Notification presence %v, want %v", got, want)
+		}
+	}
+
+	// The channel is closed when ctx is done.
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("want channel closed after ctx is done")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for channel to be closed")
+	}
+}
+
+func TestWatchResync(t *testing.T) {
+	s, us := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := s.(notifications.Watcher).Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subscribe(t, s, notifications.RepoSpec{URI: "repo"}, "", 0, user(1))
+
+	// Make more notifications than fit in the buffer, without receiving changes.
+	// All but the last buffered change get through, followed by a ChangeResync.
+	for id := uint64(1); id <= 20; id++ {
+		notify(t, s, us, 2, notifications.RepoSpec{URI: "repo"}, "issues", id, notifications.NotificationRequest{})
+	}
+	for id := uint64(1); id < 16; id++ {
+		if c := receive(t, changes); c.Op != notifications.ChangeCreated || c.ThreadID != id {
+			t.Errorf("got change %+v, want created issue %d", c, id)
+		}
+	}
+	if c := receive(t, changes); c != (notifications.Change{Op: notifications.ChangeResync}) {
+		t.Errorf("got change %+v, want resync", c)
+	}
+
+	// Once the buffer is drained, changes get through again.
+	notify(t, s, us, 2, notifications.RepoSpec{URI: "repo"}, "issues", 21, notifications.NotificationRequest{})
+	if c := receive(t, changes); c.Op != notifications.ChangeCreated || c.ThreadID != 21 {
+		t.Errorf("got change %+v, want created issue 21", c)
+	}
+}
+
+// receive receives a change from changes, failing the test if none arrives in time.
+func receive(t *testing.T, changes <-chan notifications.Change) notifications.Change {
+	t.Helper()
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+		return notifications.Change{}
+	}
+}
+
+func TestUnsubscribeAndIgnore(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+	user1 := []users.UserSpec{user(1)}
+
+	// notified makes a notification for the specified issue as another user,
+	// and reports whether target user got a new unread notification for it.
+	notified := func(issueID uint64) bool {
+		t.Helper()
+		err := s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{Repo: &repo})
+		if err != nil {
+			t.Fatal(err)
+		}
+		notify(t, s, us, 2, repo, "issues", issueID, notifications.NotificationRequest{})
+		n, err := s.Count(context.Background(), notifications.CountOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+
+	// Target user watches the repo.
+	err := s.Subscribe(context.Background(), repo, "", 0, user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !notified(1) {
+		t.Error("want notification for issue 1 while watching repo")
+	}
+
+	// Ignoring issue 1 overrides watching the repo, but only for that issue.
+	err = s.Ignore(context.Background(), repo, "issues", 1, user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notified(1) {
+		t.Error("want no notification for ignored issue 1")
+	}
+	if !notified(2) {
+		t.Error("want notification for issue 2 while watching repo")
+	}
+
+	// Subscribing to issue 1 again undoes ignoring it.
+	err = s.Subscribe(context.Background(), repo, "issues", 1, user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !notified(1) {
+		t.Error("want notification for issue 1 after subscribing to it again")
+	}
+
+	// Unsubscribing from issue 1 leaves repo watching in effect.
+	err = s.Unsubscribe(context.Background(), repo, "issues", 1, user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !notified(1) {
+		t.Error("want notification for issue 1 while still watching repo")
+	}
+
+	// Unsubscribing from the repo stops all notifications.
+	err = s.Unsubscribe(context.Background(), repo, "", 0, user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notified(1) || notified(2) {
+		t.Error("want no notifications after unsubscribing from repo")
+	}
+
+	// Ignore requires a thread.
+	err = s.Ignore(context.Background(), repo, "", 0, user1)
+	if err == nil {
+		t.Error("want error ignoring entire repo, got nil")
+	}
+}
+
+func TestListSubscriptions(t *testing.T) {
+	s, _ := newTestService(t)
+	sl := s.(notifications.SubscriptionLister)
+	repo := notifications.RepoSpec{URI: "example.org/repo"}
+	user1, user2 := user(1), user(2)
+
+	// User 1 watches the repo and ignores issue 2. User 2 participates in issue 1.
+	subscribe(t, s, repo, "", 0, user1)
+	err := s.Ignore(context.Background(), repo, "issues", 2, []users.UserSpec{user1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscribe(t, s, repo, "issues", 1, user2)
+
+	for _, tc := range []struct {
+		threadType string
+		threadID   uint64
+		want       []notifications.Subscriber
+	}{
+		{"", 0, []notifications.Subscriber{{User: user1}}},
+		{"issues", 1, []notifications.Subscriber{{User: user1}, {User: user2, Participating: true}}},
+		{"issues", 2, nil},
+	} {
+		got, err := sl.ListSubscribers(context.Background(), repo, tc.threadType, tc.threadID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ListSubscribers(%q, %d): got %+v, want %+v", tc.threadType, tc.threadID, got, tc.want)
+		}
+	}
+
+	got, err := sl.ListSubscriptions(context.Background(), user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []notifications.Subscription{
+		{RepoSpec: repo, Watch: notifications.RepoWatch{Level: notifications.WatchAll}},
+		{RepoSpec: repo, ThreadType: "issues", ThreadID: 2, Ignored: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListSubscriptions: got %+v, want %+v", got, want)
+	}
+
+	// Only the authenticated user's subscriptions can be listed.
+	_, err = sl.ListSubscriptions(context.Background(), user2)
+	if !os.IsPermission(err) {
+		t.Errorf("want permission error, got %v", err)
+	}
+}
+
+func TestListSubscriptionsRepoLikeThread(t *testing.T) {
+	mem := newMemFS(t)
+	s := fs.NewService(mem, &mockUsers{Current: user(1)})
+
+	// Repo "example.org/go-1" has a last path element that looks like a thread directory.
+	subscribe(t, s, notifications.RepoSpec{URI: "example.org/go-1"}, "", 0, user(1))
+	// A blank file written before subscriptions recorded what they're to.
+	for _, dir := range []string{"subscribers", "subscribers/example.org", "subscribers/example.org/repo", "subscribers/example.org/repo/issues-3"} {
+		err := mem.Mkdir(context.Background(), dir, 0755)
+		if err != nil && !os.IsExist(err) {
+			t.Fatal(err)
+		}
+	}
+	f, err := mem.OpenFile(context.Background(), "subscribers/example.org/repo/issues-3/1@example.org", os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := s.(notifications.SubscriptionLister).ListSubscriptions(context.Background(), user(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []notifications.Subscription{
+		{RepoSpec: notifications.RepoSpec{URI: "example.org/go-1"}, Watch: notifications.RepoWatch{Level: notifications.WatchAll}},
+		{RepoSpec: notifications.RepoSpec{URI: "example.org/repo"}, ThreadType: "issues", ThreadID: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListSubscriptions: got %+v, want %+v", got, want)
+	}
+}
+
+func TestMarkUnreadAndDelete(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	for id := uint64(1); id <= 2; id++ {
+		notify(t, s, us, 2, repo, "issues", id, notifications.NotificationRequest{Title: fmt.Sprintf("Issue %d", id)})
+	}
+
+	// Mark issue 1 read, then unread again.
+	err := s.MarkRead(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.MarkUnread(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 2 {
+		t.Errorf("want 2 unread notifications, got %d: %+v", len(ns), ns)
+	}
+
+	// Delete issue 1 while unread, and issue 2 after it's read.
+	err = s.Delete(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.MarkRead(context.Background(), repo, "issues", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Delete(context.Background(), repo, "issues", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 0 {
+		t.Errorf("want no notifications, got: %+v", ns)
+	}
+
+	// Deleting or marking unread a missing notification is not an error.
+	err = s.Delete(context.Background(), repo, "issues", 3)
+	if err != nil {
+		t.Error(err)
+	}
+	err = s.MarkUnread(context.Background(), repo, "issues", 3)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	s, us := newTestService(t)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs.SetNow(s, func() time.Time { return now })
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	count := func() uint64 {
+		n, err := s.Count(context.Background(), notifications.CountOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	for id := uint64(1); id <= 2; id++ {
+		notify(t, s, us, 2, repo, "issues", id, notifications.NotificationRequest{UpdatedAt: now})
+	}
+
+	// Snooze both issues for an hour.
+	for id := uint64(1); id <= 2; id++ {
+		err := s.Snooze(context.Background(), repo, "issues", id, now.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 0 {
+		t.Errorf("want no notifications while snoozed, got: %+v", ns)
+	}
+	if got, want := count(), uint64(0); got != want {
+		t.Errorf("got count %d, want %d", got, want)
+	}
+
+	// A newer notification of issue 2 ends its snooze early.
+	now = now.Add(30 * time.Minute)
+	notify(t, s, us, 2, repo, "issues", 2, notifications.NotificationRequest{UpdatedAt: now})
+	if got, want := count(), uint64(1); got != want {
+		t.Errorf("got count %d, want %d", got, want)
+	}
+
+	// After the deadline, issue 1 is unread again.
+	now = now.Add(30 * time.Minute)
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 2 {
+		t.Errorf("want 2 unread notifications, got %d: %+v", len(ns), ns)
+	}
+
+	// Snoozing a missing notification is not an error.
+	err = s.Snooze(context.Background(), repo, "issues", 3, now.Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaved(t *testing.T) {
+	s, us := newTestService(t)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs.SetNow(s, func() time.Time { return now })
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	for id := uint64(1); id <= 2; id++ {
+		notify(t, s, us, 2, repo, "issues", id, notifications.NotificationRequest{Title: fmt.Sprintf("Issue %d", id), UpdatedAt: now})
+	}
+
+	// Save issue 1, then read both.
+	err := s.SetSaved(context.Background(), repo, "issues", 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{Repo: &repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving is preserved when the thread is notified again.
+	notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{Title: "Issue 1", UpdatedAt: now})
+	err = s.MarkRead(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After the retention period, only the saved notification is left.
+	now = now.Add(31 * 24 * time.Hour)
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Title != "Issue 1" || !ns[0].Saved {
+		t.Errorf("want only saved Issue 1 left, got: %+v", ns)
+	}
+
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true, Saved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Title != "Issue 1" {
+		t.Errorf("want only saved Issue 1, got: %+v", ns)
+	}
+
+	// Unsaving removes it from the saved view.
+	err = s.SetSaved(context.Background(), repo, "issues", 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, _, err = s.List(context.Background(), notifications.ListOptions{All: true, Saved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 0 {
+		t.Errorf("want no saved notifications, got: %+v", ns)
+	}
+}
+
+func TestPurgeConcurrent(t *testing.T) {
+	s, us := newTestService(t)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs.SetNow(s, func() time.Time { return now })
+	repo := notifications.RepoSpec{URI: "repo"}
+	subscribe(t, s, repo, "", 0, user(1))
+	notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{UpdatedAt: now})
+	err := s.MarkRead(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := s.(notifications.Watcher).Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After the retention period, list concurrently.
+	now = now.Add(31 * 24 * time.Hour)
+	errs := make(chan error)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, _, err := s.List(context.Background(), notifications.ListOptions{All: true})
+			errs <- err
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The purged notification is deleted exactly once.
+	notify(t, s, us, 2, repo, "issues", 2, notifications.NotificationRequest{UpdatedAt: now})
+	var deleted int
+	for c := receive(t, changes); c.Op != notifications.ChangeCreated; c = receive(t, changes) {
+		if c.Op == notifications.ChangeDeleted && c.ThreadID == 1 {
+			deleted++
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("got %d deleted changes, want 1", deleted)
+	}
+}
+
+func TestBody(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+	subscribe(t, s, repo, "", 0, user(1))
+
+	// The body is that of the latest Notify.
+	for _, body := range []string{"First comment.", "Second comment."} {
+		notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{Body: body})
+	}
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Body != "Second comment." {
+		t.Errorf(`want 1 notification with body "Second comment.", got: %+v`, ns)
+	}
+}
+
+func TestActors(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	check := func(wantActors []uint64, wantCount int) {
+		t.Helper()
+		ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ns) != 1 {
+			t.Fatalf("want 1 notification, got: %+v", ns)
+		}
+		var actors []uint64
+		for _, a := range ns[0].Actors {
+			actors = append(actors, a.ID)
+		}
+		if !reflect.DeepEqual(actors, wantActors) {
+			t.Errorf("got actors %v, want %v", actors, wantActors)
+		}
+		if ns[0].EventCount != wantCount {
+			t.Errorf("got event count %d, want %d", ns[0].EventCount, wantCount)
+		}
+	}
+
+	for _, actorID := range []uint64{2, 3, 2} {
+		notify(t, s, us, actorID, repo, "issues", 1, notifications.NotificationRequest{})
+	}
+	check([]uint64{2, 3}, 3)
+
+	// Reading the thread starts over.
+	err := s.MarkRead(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notify(t, s, us, 4, repo, "issues", 1, notifications.NotificationRequest{})
+	check([]uint64{4}, 1)
+}
+
+func TestListThreadEvents(t *testing.T) {
+	s, us := newTestService(t)
+	tel := s.(notifications.ThreadEventLister)
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	comment := func(comment int) {
+		t.Helper()
+		notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{
+			UpdatedAt: t0.Add(time.Duration(comment) * time.Minute),
+			HTMLURL:   fmt.Sprintf("/issues/1#comment-%d", comment),
+		})
+	}
+	list := func() string {
+		t.Helper()
+		es, err := tel.ListThreadEvents(context.Background(), repo, "issues", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range es {
+			got = append(got, fmt.Sprintf("%s read=%v", e.HTMLURL, e.Read))
+		}
+		return fmt.Sprint(got)
+	}
+
+	comment(1)
+	err := s.MarkRead(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment(2)
+	comment(3)
+	if got, want := list(), "[/issues/1#comment-1 read=true /issues/1#comment-2 read=false /issues/1#comment-3 read=false]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Deleting the notification deletes its events too.
+	err = s.Delete(context.Background(), repo, "issues", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := list(), "[]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	comment(4)
+	if got, want := list(), "[/issues/1#comment-4 read=false]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestKindAndState(t *testing.T) {
+	s, us := newTestService(t)
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+	for id, nr := range map[uint64]notifications.NotificationRequest{
+		1: {Kind: notifications.KindPRMerged, State: notifications.SubjectStateMerged},
+		// Legacy requests with only an Octicon ID.
+		2: {Icon: "issue-closed"},
+		3: {Icon: "git-pull-request", Color: notifications.RGB{R: 0xbd, G: 0x2c, B: 0x00}},
+	} {
+		nr.Title = fmt.Sprint(id)
+		notify(t, s, us, 2, repo, "issues", id, nr)
+	}
+
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range ns {
+		got = append(got, fmt.Sprintf("%s:%s:%s:%s", n.Title, n.Kind, n.Icon, n.State))
+	}
+	sort.Strings(got)
+	if want := "[1:pr-merged:git-pull-request:merged 2:issue-closed:issue-closed: 3:pr-closed:git-pull-request:]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMarkAllReadGlobal(t *testing.T) {
+	s, us := newTestService(t)
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := t0
+	fs.SetNow(s, func() time.Time { return now })
+
+	for i, uri := range []string{"a", "b", "c"} {
+		now = t0.Add(time.Duration(i) * time.Minute)
+		repo := notifications.RepoSpec{URI: uri}
+		subscribe(t, s, repo, "", 0, user(1))
+		notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{Title: uri, UpdatedAt: now})
+	}
+
+	// Mark read across all repos, as of when the first two notifications were seen.
+	err := s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{LastReadAt: t0.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Title != "c" {
+		t.Errorf(`want 1 unread notification "c", got: %+v`, ns)
+	}
+
+	// Mark everything read.
+	err = s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Count(context.Background(), notifications.CountOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("want no unread notifications, got %d", n)
+	}
+}
+
+func TestMarkAllReadRace(t *testing.T) {
+	s, us := newTestService(t)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs.SetNow(s, func() time.Time { return now })
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	subscribe(t, s, repo, "", 0, user(1))
+
+	for _, tc := range []struct {
+		name string
+		opt  notifications.MarkAllReadOptions
+	}{
+		{"repo", notifications.MarkAllReadOptions{Repo: &repo}},
+		{"global", notifications.MarkAllReadOptions{}},
+	} {
+		// The user sees issue 1.
+		notify(t, s, us, 2, repo, "issues", 1, notifications.NotificationRequest{Title: "Issue 1", UpdatedAt: now})
+		now = now.Add(time.Minute)
+		seenAt := now
+
+		// Issue 2 arrives after the user loaded the page,
+		// even though its subject was updated before that.
+		now = now.Add(time.Minute)
+		notify(t, s, us, 2, repo, "issues", 2, notifications.NotificationRequest{Title: "Issue 2", UpdatedAt: seenAt.Add(-time.Second)})
+
+		// The user marks all read as of what they saw.
+		tc.opt.LastReadAt = seenAt
+		err := s.MarkAllRead(context.Background(), tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ns) != 1 || ns[0].Title != "Issue 2" {
+			t.Errorf(`%s: want 1 unread notification "Issue 2", got: %+v`, tc.name, ns)
+		}
+
+		// Clean up for next case.
+		err = s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	s, us := newTestService(t)
+	ps := s.(notifications.PreferencesService)
+	user1 := user(1)
+
+	// Users who never set preferences get the zero value.
+	got, err := ps.GetPreferences(context.Background(), user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, notifications.Preferences{}) {
+		t.Errorf("got %+v, want zero preferences", got)
+	}
+
+	want := notifications.Preferences{
+		Delivery: notifications.Delivery{Reasons: []notifications.Reason{notifications.ReasonMention}},
+		Repos: map[notifications.RepoSpec]notifications.Delivery{
+			{URI: "b"}: {},
+			{URI: "c"}: {Channels: []notifications.Channel{notifications.ChannelEmail}},
+		},
+	}
+	err = ps.SetPreferences(context.Background(), user1, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ps.GetPreferences(context.Background(), user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Other users' preferences are off limits.
+	_, err = ps.GetPreferences(context.Background(), user(2))
+	if !os.IsPermission(err) {
+		t.Errorf("want permission error, got %v", err)
+	}
+
+	// Notify watched repos, with and without mentioning user 1.
+	for _, uri := range []string{"a", "b", "c"} {
+		subscribe(t, s, notifications.RepoSpec{URI: uri}, "", 0, user1)
+		for id, mentions := range map[uint64][]users.UserSpec{1: nil, 2: {user1}} {
+			notify(t, s, us, 2, notifications.RepoSpec{URI: uri}, "issues", id,
+				notifications.NotificationRequest{
+					Title:    fmt.Sprintf("%s %d", uri, id),
+					Mentions: mentions,
+				})
+		}
+	}
+
+	ns, _, err := s.List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, n := range ns {
+		titles = append(titles, n.Title)
+	}
+	sort.Strings(titles)
+	if got, want := fmt.Sprint(titles), "[a 2 b 1 b 2]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRepoWatch(t *testing.T) {
+	s, _ := newTestService(t)
+	rw := s.(notifications.RepoWatchService)
+	repo := notifications.RepoSpec{URI: "repo"}
+
+	// User 1 watches all, user 2 is participating only,
+	// user 3 ignores the repo, and user 4 watches releases only.
+	subscribe(t, s, repo, "", 0, user(1))
+	for id, w := range map[uint64]notifications.RepoWatch{
+		2: {Level: notifications.WatchParticipating},
+		3: {Level: notifications.WatchIgnore},
+		4: {Level: notifications.WatchCustom, ThreadTypes: []string{"releases"}},
+	} {
+		err := rw.SetRepoWatch(context.Background(), repo, []users.UserSpec{user(id)}, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Users 2 and 3 participate in issue 1.
+	subscribe(t, s, repo, "issues", 1, user(2), user(3))
+
+	w, err := rw.GetRepoWatch(context.Background(), repo, user(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Level != notifications.WatchAll {
+		t.Errorf("got watch level %q, want %q", w.Level, notifications.WatchAll)
+	}
+
+	sl := s.(notifications.SubscriptionLister)
+	for _, tc := range []struct {
+		threadType string
+		threadID   uint64
+		want       string
+	}{
+		{"issues", 1, "[1 2]"},
+		{"issues", 2, "[1]"},
+		{"releases", 1, "[1 4]"},
+		{"", 0, "[1 4]"},
+	} {
+		ss, err := sl.ListSubscribers(context.Background(), repo, tc.threadType, tc.threadID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []uint64
+		for _, s := range ss {
+			ids = append(ids, s.User.ID)
+		}
+		if got := fmt.Sprint(ids); got != tc.want {
+			t.Errorf("%s %d: got subscribers %v, want %v", tc.threadType, tc.threadID, got, tc.want)
+		}
+	}
+}
+
+// newTestService returns a service backed by a new in-memory filesystem,
+// and its users service, with user 1 authenticated.
+func newTestService(t *testing.T) (notifications.Service, *mockUsers) {
+	us := &mockUsers{Current: user(1)}
+	return fs.NewService(newMemFS(t), us), us
+}
+
+// user returns the spec of user id at example.org.
+func user(id uint64) users.UserSpec {
+	return users.UserSpec{ID: id, Domain: "example.org"}
+}
+
+// subscribe subscribes subscribers to the specified thread, or repo if threadID is 0.
+func subscribe(t *testing.T, s notifications.Service, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers ...users.UserSpec) {
+	t.Helper()
+	err := s.Subscribe(context.Background(), repo, threadType, threadID, subscribers)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// notify makes notification nr of the specified thread as user actorID,
+// then switches back to the previously authenticated user.
+// nr.Actor is set to the actor, and a zero nr.UpdatedAt to the current time.
+func notify(t *testing.T, s notifications.Service, us *mockUsers, actorID uint64, repo notifications.RepoSpec, threadType string, threadID uint64, nr notifications.NotificationRequest) {
+	t.Helper()
+	current := us.Current
+	us.Current = user(actorID)
+	defer func() { us.Current = current }()
+	nr.Actor = us.Current
+	if nr.UpdatedAt.IsZero() {
+		nr.UpdatedAt = time.Now()
+	}
+	err := s.Notify(context.Background(), repo, threadType, threadID, nr)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newMemFS returns an in-memory filesystem
+// with top-level notifications and read directories.
+func newMemFS(t *testing.T) webdav.FileSystem {
+	mem := webdav.NewMemFS()
+	for _, dir := range []string{"notifications", "read"} {
+		err := mem.Mkdir(context.Background(), dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mem
+}
+
 type mockUsers struct {
 	Current users.UserSpec
 	users.Service
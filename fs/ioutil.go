@@ -3,8 +3,8 @@ package fs
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
-	pathpkg "path"
 
 	"github.com/shurcooL/webdavfs/vfsutil"
 	"golang.org/x/net/webdav"
@@ -30,19 +30,26 @@ func jsonDecodeFile(ctx context.Context, fs webdav.FileSystem, path string, v in
 	return json.NewDecoder(f).Decode(v)
 }
 
-// createEmptyFile creates an empty file at path, creating parent directories if needed.
-func createEmptyFile(ctx context.Context, fs webdav.FileSystem, path string) error {
-	f, err := vfsutil.Create(ctx, fs, path)
-	if os.IsNotExist(err) {
-		err = vfsutil.MkdirAll(ctx, fs, pathpkg.Dir(path), 0755)
-		if err != nil {
-			return err
-		}
-		f, err = vfsutil.Create(ctx, fs, path)
+// readSubscription reads the subscription in file at path.
+// A blank file is a zero-value subscription.
+func readSubscription(ctx context.Context, fs webdav.FileSystem, path string) (subscription, error) {
+	var sub subscription
+	err := jsonDecodeFile(ctx, fs, path, &sub)
+	if err == io.EOF {
+		return subscription{}, nil
 	}
-	if err != nil {
+	return sub, err
+}
+
+// removeIfEmpty removes directory dir if it exists and is empty.
+//
+// THINK: Consider using the dir-less vfs abstraction for doing this implicitly? Less code for callers.
+func removeIfEmpty(ctx context.Context, fs webdav.FileSystem, dir string) error {
+	switch fis, err := vfsutil.ReadDir(ctx, fs, dir); {
+	case err != nil && !os.IsNotExist(err):
 		return err
+	case err == nil && len(fis) == 0:
+		return fs.RemoveAll(ctx, dir)
 	}
-	_ = f.Close()
 	return nil
 }
@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+	"github.com/shurcooL/webdavfs/vfsutil"
+)
+
+var _ notifications.PreferencesService = &service{}
+
+func (s *service) GetPreferences(ctx context.Context, user users.UserSpec) (notifications.Preferences, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+	if currentUser.ID == 0 || user != currentUser {
+		return notifications.Preferences{}, os.ErrPermission
+	}
+
+	s.fsMu.RLock()
+	defer s.fsMu.RUnlock()
+
+	p, err := s.preferences(ctx, user)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+	return p.Preferences(), nil
+}
+
+func (s *service) SetPreferences(ctx context.Context, user users.UserSpec, p notifications.Preferences) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 || user != currentUser {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	// Create preferences directory in case it doesn't already exist.
+	err = vfsutil.MkdirAll(ctx, s.fs, path.Dir(preferencesPath(user)), 0755)
+	if err != nil {
+		return err
+	}
+	err = jsonEncodeFile(ctx, s.fs, preferencesPath(user), fromPreferences(p))
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", preferencesPath(user), err)
+	}
+	return nil
+}
+
+// preferences returns the preferences of user,
+// or zero-value preferences if user never set any.
+// s.fsMu must be held.
+func (s *service) preferences(ctx context.Context, user users.UserSpec) (preferences, error) {
+	var p preferences
+	err := jsonDecodeFile(ctx, s.fs, preferencesPath(user), &p)
+	if os.IsNotExist(err) {
+		return preferences{}, nil
+	} else if err != nil {
+		return preferences{}, fmt.Errorf("error reading %s: %v", preferencesPath(user), err)
+	}
+	return p, nil
+}
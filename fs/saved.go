@@ -0,0 +1,59 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/shurcooL/notifications"
+)
+
+func (s *service) SetSaved(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, saved bool) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	// The notification may be unread, read, or snoozed.
+	key := notificationKey(repo, threadType, threadID)
+	for _, path := range []string{notificationPath(currentUser, key), readPath(currentUser, key), snoozedPath(currentUser, key)} {
+		// A snoozedNotification decodes from any of the paths,
+		// with zero Until for the ones that aren't snoozed.
+		var sn snoozedNotification
+		err := jsonDecodeFile(ctx, s.fs, path, &sn)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		if sn.Saved == saved {
+			return nil
+		}
+		sn.Saved = saved
+
+		var v interface{} = sn.notification
+		if path == snoozedPath(currentUser, key) {
+			v = sn
+		}
+		err = jsonEncodeFile(ctx, s.fs, path, v)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", path, err)
+		}
+
+		op := notifications.ChangeSaved
+		if !saved {
+			op = notifications.ChangeUnsaved
+		}
+		s.emit(currentUser, notifications.Change{Op: op, RepoSpec: repo, ThreadType: threadType, ThreadID: threadID})
+		return nil
+	}
+
+	// Nothing to save.
+	return nil
+}
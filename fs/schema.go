@@ -3,6 +3,7 @@ package fs
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -90,30 +91,269 @@ type notification struct {
 	ThreadType string `json:"AppID"` // TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
 	ThreadID   uint64 // TODO: Maybe deduce threadType and threadID from fi.Name() rather than adding that to encoded JSON...
 	Title      string
-	Icon       octiconID
+	Kind       string    `json:",omitempty"`
+	State      string    `json:",omitempty"`
+	Icon       octiconID `json:",omitempty"` // Only used if Kind is empty.
 	Color      rgb
 	Actor      userSpec
+	Actors     []userSpec `json:",omitempty"` // Most recent first.
+	EventCount int        `json:",omitempty"`
 	UpdatedAt  time.Time
+	ReceivedAt time.Time `json:",omitempty"` // When the notification arrived, as opposed to when its subject was updated.
 	HTMLURL    string
+	Body       string `json:",omitempty"`
 
+	Reason        string `json:",omitempty"`
 	Participating bool
+	Mentioned     bool `json:",omitempty"`
+	Saved         bool `json:",omitempty"`
+}
+
+// snoozedNotification is an on-disk representation of a snoozed notification.
+type snoozedNotification struct {
+	notification
+	Until time.Time // When the notification is unread again.
+}
+
+// receivedAt returns when n arrived.
+func (n notification) receivedAt() time.Time {
+	if n.ReceivedAt.IsZero() {
+		// Notifications stored without an arrival time
+		// are assumed to have arrived when updated.
+		return n.UpdatedAt
+	}
+	return n.ReceivedAt
+}
+
+// kind returns the kind of n. Notifications stored without a kind
+// are mapped from their Octicon ID, and color where that's ambiguous.
+func (n notification) kind() notifications.Kind {
+	if n.Kind != "" {
+		return notifications.Kind(n.Kind)
+	}
+	switch n.Icon {
+	case "issue-opened":
+		return notifications.KindIssueOpen
+	case "issue-closed":
+		return notifications.KindIssueClosed
+	case "git-pull-request":
+		switch n.Color {
+		case rgb{R: 0xbd, G: 0x2c, B: 0x00}: // Red.
+			return notifications.KindPRClosed
+		case rgb{R: 0x6e, G: 0x54, B: 0x94}: // Purple.
+			return notifications.KindPRMerged
+		default:
+			return notifications.KindPROpen
+		}
+	case "tag":
+		return notifications.KindRelease
+	case "git-commit":
+		return notifications.KindCommit
+	case "mail":
+		return notifications.KindInvitation
+	default:
+		return ""
+	}
+}
+
+// reason returns the reason of n.
+func (n notification) reason() notifications.Reason {
+	switch {
+	case n.Reason != "":
+		return notifications.Reason(n.Reason)
+	case n.Mentioned:
+		return notifications.ReasonMention
+	case !n.Participating:
+		// Notifications stored without a reason are known
+		// to be from watching the repo if not participating.
+		return notifications.ReasonSubscribed
+	default:
+		return ""
+	}
+}
+
+// actors returns distinct actors of n, most recent first.
+func (n notification) actors() []userSpec {
+	if len(n.Actors) == 0 {
+		// Notifications stored without actors only know the latest one.
+		return []userSpec{n.Actor}
+	}
+	return n.Actors
+}
+
+// eventCount returns the number of events of n.
+func (n notification) eventCount() int {
+	if n.EventCount == 0 {
+		// Notifications stored without an event count have at least one.
+		return 1
+	}
+	return n.EventCount
+}
+
+// matches reports whether n matches the filters in opt.
+func (n notification) matches(opt notifications.ListOptions) bool {
+	switch {
+	case opt.Repo != nil && n.RepoSpec.RepoSpec() != *opt.Repo:
+		return false
+	case !opt.Since.IsZero() && !n.UpdatedAt.After(opt.Since):
+		return false
+	case !opt.Before.IsZero() && !n.UpdatedAt.Before(opt.Before):
+		return false
+	case opt.ThreadType != "" && n.ThreadType != opt.ThreadType:
+		return false
+	case opt.Reason != "" && n.reason() != opt.Reason:
+		return false
+	case opt.Participating && !n.Participating:
+		return false
+	case opt.Mentioned && !n.Mentioned:
+		return false
+	case opt.Saved && !n.Saved:
+		return false
+	case opt.Actor != nil && !n.Actor.Equal(*opt.Actor):
+		return false
+	default:
+		return true
+	}
+}
+
+// event is an on-disk representation of notifications.ThreadEvent.
+type event struct {
+	Title     string
+	Actor     userSpec
+	CreatedAt time.Time
+	HTMLURL   string
+	Body      string `json:",omitempty"`
+}
+
+// subscription is an on-disk representation of a subscription.
+// A blank file, as created by Subscribe before it recorded the thread,
+// represents a zero-value subscription.
+type subscription struct {
+	// Repo, ThreadType and ThreadID identify what is subscribed to.
+	// ThreadType and ThreadID are zero for repo subscriptions.
+	// Repo is empty in blank files and in ones written before it was recorded,
+	// where the subscription is inferred from its directory instead.
+	Repo       string `json:",omitempty"`
+	ThreadType string `json:",omitempty"`
+	ThreadID   uint64 `json:",omitempty"`
+
+	Ignored bool `json:",omitempty"` // Whether the thread is ignored, only valid for thread subscriptions.
+
+	// Watch level, only valid for repo subscriptions. Empty means all.
+	Level       string   `json:",omitempty"`
+	ThreadTypes []string `json:",omitempty"` // Only valid for custom watch level.
+}
+
+// newSubscription returns a subscription to the specified thread,
+// or to the entire repo if threadType and threadID are zero.
+func newSubscription(repo notifications.RepoSpec, threadType string, threadID uint64) subscription {
+	return subscription{Repo: repo.URI, ThreadType: threadType, ThreadID: threadID}
+}
+
+// watch returns the watch level of repo subscription sub.
+func (sub subscription) watch() notifications.RepoWatch {
+	if sub.Level == "" {
+		// A blank file, or one without a watch level, is watching all activity.
+		return notifications.RepoWatch{Level: notifications.WatchAll}
+	}
+	return notifications.RepoWatch{
+		Level:       notifications.WatchLevel(sub.Level),
+		ThreadTypes: sub.ThreadTypes,
+	}
+}
+
+// preferences is an on-disk representation of notifications.Preferences.
+type preferences struct {
+	Delivery delivery
+	Repos    []repoDelivery `json:",omitempty"` // Sorted by repo.
+}
+
+// delivery is an on-disk representation of notifications.Delivery.
+type delivery struct {
+	Reasons     []string `json:",omitempty"`
+	ThreadTypes []string `json:",omitempty"`
+	Channels    []string `json:",omitempty"`
+}
+
+type repoDelivery struct {
+	Repo     repoSpec
+	Delivery delivery
+}
+
+func fromPreferences(p notifications.Preferences) preferences {
+	var repos []repoDelivery
+	for repo, d := range p.Repos {
+		repos = append(repos, repoDelivery{Repo: fromRepoSpec(repo), Delivery: fromDelivery(d)})
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Repo.URI < repos[j].Repo.URI })
+	return preferences{
+		Delivery: fromDelivery(p.Delivery),
+		Repos:    repos,
+	}
+}
+
+func (p preferences) Preferences() notifications.Preferences {
+	var repos map[notifications.RepoSpec]notifications.Delivery
+	if len(p.Repos) > 0 {
+		repos = make(map[notifications.RepoSpec]notifications.Delivery)
+	}
+	for _, r := range p.Repos {
+		repos[r.Repo.RepoSpec()] = r.Delivery.Delivery()
+	}
+	return notifications.Preferences{
+		Delivery: p.Delivery.Delivery(),
+		Repos:    repos,
+	}
+}
+
+func fromDelivery(d notifications.Delivery) delivery {
+	var dd delivery
+	for _, r := range d.Reasons {
+		dd.Reasons = append(dd.Reasons, string(r))
+	}
+	dd.ThreadTypes = append(dd.ThreadTypes, d.ThreadTypes...)
+	for _, c := range d.Channels {
+		dd.Channels = append(dd.Channels, string(c))
+	}
+	return dd
+}
+
+func (d delivery) Delivery() notifications.Delivery {
+	var dd notifications.Delivery
+	for _, r := range d.Reasons {
+		dd.Reasons = append(dd.Reasons, notifications.Reason(r))
+	}
+	dd.ThreadTypes = append(dd.ThreadTypes, d.ThreadTypes...)
+	for _, c := range d.Channels {
+		dd.Channels = append(dd.Channels, notifications.Channel(c))
+	}
+	return dd
 }
 
 // Tree layout:
 //
 // 	root
+// 	├── events - events of notified threads
+// 	│   └── userSpec
+// 	│       └── domain.com-path-threadType-threadID
+// 	│           └── seq - encoded event
 // 	├── notifications - unread notifications only
 // 	│   └── userSpec
 // 	│       └── domain.com-path-threadType-threadID - encoded notification
+// 	├── preferences
+// 	│   └── userSpec - encoded preferences
 // 	├── read - read notifications only
 // 	│   └── userSpec
 // 	│       └── domain.com-path-threadType-threadID - encoded notification
+// 	├── snoozed - snoozed notifications only
+// 	│   └── userSpec
+// 	│       └── domain.com-path-threadType-threadID - encoded snoozedNotification
 // 	└── subscribers
 // 	    └── domain.com
 // 	        └── path
 // 	            ├── threadType-threadID
-// 	            │   └── userSpec - blank file
-// 	            └── userSpec - blank file
+// 	            │   └── userSpec - blank file or encoded subscription
+// 	            └── userSpec - blank file or encoded subscription
 //
 // ThreadType is primarily needed to separate namespaces of {Repo, ThreadID}.
 // Without ThreadType, a notification about issue 1 in repo "a" would clash
@@ -127,6 +367,10 @@ func notificationPath(user users.UserSpec, key string) string {
 	return path.Join(notificationsDir(user), key)
 }
 
+func preferencesPath(user users.UserSpec) string {
+	return path.Join("preferences", marshalUserSpec(user))
+}
+
 func readDir(user users.UserSpec) string {
 	return path.Join("read", marshalUserSpec(user))
 }
@@ -135,17 +379,40 @@ func readPath(user users.UserSpec, key string) string {
 	return path.Join(readDir(user), key)
 }
 
+func snoozedDir(user users.UserSpec) string {
+	return path.Join("snoozed", marshalUserSpec(user))
+}
+
+func snoozedPath(user users.UserSpec, key string) string {
+	return path.Join(snoozedDir(user), key)
+}
+
+func userEventsDir(user users.UserSpec) string {
+	return path.Join("events", marshalUserSpec(user))
+}
+
+func eventsDir(user users.UserSpec, key string) string {
+	return path.Join(userEventsDir(user), key)
+}
+
+func eventPath(user users.UserSpec, key string, seq uint64) string {
+	return path.Join(eventsDir(user, key), fmt.Sprintf("%010d", seq))
+}
+
 func notificationKey(repo notifications.RepoSpec, threadType string, threadID uint64) string {
 	// TODO: Think about repo.URI replacement of "/" -> "-", is it optimal?
 	return fmt.Sprintf("%s-%s-%d", strings.Replace(repo.URI, "/", "-", -1), threadType, threadID)
 }
 
+// subscribersRoot is the root of the subscribers tree.
+const subscribersRoot = "subscribers"
+
 func subscribersDir(repo notifications.RepoSpec, threadType string, threadID uint64) string {
 	switch {
 	default:
-		return path.Join("subscribers", repo.URI, fmt.Sprintf("%s-%d", threadType, threadID))
+		return path.Join(subscribersRoot, repo.URI, fmt.Sprintf("%s-%d", threadType, threadID))
 	case threadType == "" && threadID == 0:
-		return path.Join("subscribers", repo.URI)
+		return path.Join(subscribersRoot, repo.URI)
 	}
 }
 
@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+	"github.com/shurcooL/webdavfs/vfsutil"
+)
+
+func (s *service) Snooze(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, until time.Time) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	// Find the notification, which may be either unread or read.
+	key := notificationKey(repo, threadType, threadID)
+	var (
+		path string
+		n    notification
+	)
+	for _, p := range []string{notificationPath(currentUser, key), readPath(currentUser, key)} {
+		err := jsonDecodeFile(ctx, s.fs, p, &n)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error reading %s: %v", p, err)
+		}
+		path = p
+		break
+	}
+	if path == "" {
+		// Nothing to snooze.
+		return nil
+	}
+
+	// Create snoozedDir for currentUser in case it doesn't already exist.
+	err = vfsutil.MkdirAll(ctx, s.fs, snoozedDir(currentUser), 0755)
+	if err != nil {
+		return err
+	}
+	// Move notification to snoozed directory.
+	err = jsonEncodeFile(ctx, s.fs, snoozedPath(currentUser, key), snoozedNotification{notification: n, Until: until})
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", snoozedPath(currentUser, key), err)
+	}
+	err = s.fs.RemoveAll(ctx, path)
+	if err != nil {
+		return err
+	}
+	s.emit(currentUser, notifications.Change{Op: notifications.ChangeSnoozed, RepoSpec: repo, ThreadType: threadType, ThreadID: threadID})
+
+	// If the user has no more unread or read notifications left, remove the empty directories.
+	err = removeIfEmpty(ctx, s.fs, notificationsDir(currentUser))
+	if err != nil {
+		return err
+	}
+	return removeIfEmpty(ctx, s.fs, readDir(currentUser))
+}
+
+// unsnooze moves snoozed notifications of user whose snooze has ended
+// back to unread notifications.
+func (s *service) unsnooze(ctx context.Context, user users.UserSpec) error {
+	// Usually no snooze has ended, so check for that with only a read lock held.
+	s.fsMu.RLock()
+	ended, err := s.snoozeEnded(ctx, user)
+	s.fsMu.RUnlock()
+	if err != nil || !ended {
+		return err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	fis, err := vfsutil.ReadDir(ctx, s.fs, snoozedDir(user))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	now := s.now()
+	madeNotificationsDir := false
+	for _, fi := range fis {
+		var sn snoozedNotification
+		err := jsonDecodeFile(ctx, s.fs, snoozedPath(user, fi.Name()), &sn)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", snoozedPath(user, fi.Name()), err)
+		}
+		if sn.Until.After(now) {
+			continue
+		}
+
+		// Create notificationsDir for user in case it doesn't already exist.
+		if !madeNotificationsDir {
+			err = s.fs.Mkdir(ctx, notificationsDir(user), 0755)
+			if err != nil && !os.IsExist(err) {
+				return err
+			}
+			madeNotificationsDir = true
+		}
+		// Move notification back to notifications directory.
+		// It arrives anew, as far as the user is concerned.
+		sn.ReceivedAt = now
+		err = jsonEncodeFile(ctx, s.fs, notificationPath(user, fi.Name()), sn.notification)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", notificationPath(user, fi.Name()), err)
+		}
+		err = s.fs.RemoveAll(ctx, snoozedPath(user, fi.Name()))
+		if err != nil {
+			return err
+		}
+
+		if s.watched(user) {
+			changed := s.notification(ctx, sn.notification, false)
+			s.emit(user, notifications.Change{
+				Op:           notifications.ChangeCreated,
+				RepoSpec:     changed.RepoSpec,
+				ThreadType:   changed.ThreadType,
+				ThreadID:     changed.ThreadID,
+				Notification: &changed,
+			})
+		}
+	}
+
+	// If the user has no more snoozed notifications left, remove the empty directory.
+	return removeIfEmpty(ctx, s.fs, snoozedDir(user))
+}
+
+// snoozeEnded reports whether user has any snoozed notifications whose snooze has ended.
+// s.fsMu must be held.
+func (s *service) snoozeEnded(ctx context.Context, user users.UserSpec) (bool, error) {
+	fis, err := vfsutil.ReadDir(ctx, s.fs, snoozedDir(user))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	now := s.now()
+	for _, fi := range fis {
+		var sn snoozedNotification
+		err := jsonDecodeFile(ctx, s.fs, snoozedPath(user, fi.Name()), &sn)
+		if err != nil {
+			return false, fmt.Errorf("error reading %s: %v", snoozedPath(user, fi.Name()), err)
+		}
+		if !sn.Until.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
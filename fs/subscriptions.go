@@ -0,0 +1,168 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+	"github.com/shurcooL/webdavfs/vfsutil"
+)
+
+var _ notifications.SubscriptionLister = &service{}
+
+func (s *service) ListSubscribers(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) ([]notifications.Subscriber, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser.ID == 0 {
+		return nil, os.ErrPermission
+	}
+
+	s.fsMu.RLock()
+	defer s.fsMu.RUnlock()
+
+	subscribers, err := s.subscribers(ctx, repo, threadType, threadID)
+	if err != nil {
+		return nil, err
+	}
+	var ss []notifications.Subscriber
+	for user, participating := range subscribers {
+		ss = append(ss, notifications.Subscriber{User: user, Participating: participating})
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].User.Domain != ss[j].User.Domain {
+			return ss[i].User.Domain < ss[j].User.Domain
+		}
+		return ss[i].User.ID < ss[j].User.ID
+	})
+	return ss, nil
+}
+
+func (s *service) ListSubscriptions(ctx context.Context, user users.UserSpec) ([]notifications.Subscription, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser.ID == 0 || user != currentUser {
+		return nil, os.ErrPermission
+	}
+
+	s.fsMu.RLock()
+	defer s.fsMu.RUnlock()
+
+	// Walk the entire subscribers tree, looking for files of user.
+	var subscriptions []notifications.Subscription
+	err = vfsutil.Walk(ctx, s.fs, subscribersRoot, func(p string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) && p == subscribersRoot {
+			// No subscribers at all.
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fi.IsDir() || fi.Name() != marshalUserSpec(user) {
+			return nil
+		}
+		sub, err := readSubscription(ctx, s.fs, p)
+		if err != nil {
+			return err
+		}
+		if sub.Repo == "" {
+			// Legacy subscription that doesn't record what it's to.
+			sub.Repo, sub.ThreadType, sub.ThreadID = parseSubscribersDir(path.Dir(p))
+		}
+		if sub.ThreadType == "" && sub.ThreadID == 0 {
+			subscriptions = append(subscriptions, notifications.Subscription{
+				RepoSpec: notifications.RepoSpec{URI: sub.Repo},
+				Watch:    sub.watch(),
+			})
+			return nil
+		}
+		subscriptions = append(subscriptions, notifications.Subscription{
+			RepoSpec:   notifications.RepoSpec{URI: sub.Repo},
+			ThreadType: sub.ThreadType,
+			ThreadID:   sub.ThreadID,
+			Ignored:    sub.Ignored,
+		})
+		return nil
+	})
+	return subscriptions, err
+}
+
+// parseSubscribersDir parses a directory created by subscribersDir
+// into what its subscriptions are to. It's only used for legacy subscriptions
+// that don't record that, and it's ambiguous: a repo whose last path element
+// looks like "name-123" is taken to be thread 123 of type "name".
+func parseSubscribersDir(dir string) (repo, threadType string, threadID uint64) {
+	dir = strings.TrimPrefix(dir, subscribersRoot+"/")
+	name := path.Base(dir)
+	i := strings.LastIndex(name, "-")
+	if i <= 0 {
+		return dir, "", 0
+	}
+	threadID, err := strconv.ParseUint(name[i+1:], 10, 64)
+	if err != nil {
+		return dir, "", 0
+	}
+	return path.Dir(dir), name[:i], threadID
+}
+
+var _ notifications.RepoWatchService = &service{}
+
+func (s *service) SetRepoWatch(ctx context.Context, repo notifications.RepoSpec, subscribers []users.UserSpec, w notifications.RepoWatch) error {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser.ID == 0 {
+		return os.ErrPermission
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	sub := newSubscription(repo, "", 0)
+	sub.Level = string(w.Level)
+	if w.Level == notifications.WatchCustom {
+		sub.ThreadTypes = w.ThreadTypes
+	}
+	err = vfsutil.MkdirAll(ctx, s.fs, subscribersDir(repo, "", 0), 0755)
+	if err != nil {
+		return err
+	}
+	for _, subscriber := range subscribers {
+		err := jsonEncodeFile(ctx, s.fs, subscriberPath(repo, "", 0, subscriber), sub)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", subscriberPath(repo, "", 0, subscriber), err)
+		}
+	}
+	return nil
+}
+
+func (s *service) GetRepoWatch(ctx context.Context, repo notifications.RepoSpec, user users.UserSpec) (notifications.RepoWatch, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return notifications.RepoWatch{}, err
+	}
+	if currentUser.ID == 0 || user != currentUser {
+		return notifications.RepoWatch{}, os.ErrPermission
+	}
+
+	s.fsMu.RLock()
+	defer s.fsMu.RUnlock()
+
+	sub, err := readSubscription(ctx, s.fs, subscriberPath(repo, "", 0, user))
+	if os.IsNotExist(err) {
+		// Not watching the repo.
+		return notifications.RepoWatch{Level: notifications.WatchParticipating}, nil
+	} else if err != nil {
+		return notifications.RepoWatch{}, err
+	}
+	return sub.watch(), nil
+}
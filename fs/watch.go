@@ -0,0 +1,83 @@
+package fs
+
+import (
+	"context"
+	"os"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+)
+
+var _ notifications.Watcher = &service{}
+
+func (s *service) Watch(ctx context.Context) (<-chan notifications.Change, error) {
+	currentUser, err := s.users.GetAuthenticatedSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser.ID == 0 {
+		return nil, os.ErrPermission
+	}
+
+	ch := make(chan notifications.Change, watchBuffer)
+	s.watchersMu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[users.UserSpec]map[chan notifications.Change]bool)
+	}
+	if s.watchers[currentUser] == nil {
+		s.watchers[currentUser] = make(map[chan notifications.Change]bool)
+	}
+	s.watchers[currentUser][ch] = false
+	s.watchersMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.watchersMu.Lock()
+		delete(s.watchers[currentUser], ch)
+		if len(s.watchers[currentUser]) == 0 {
+			delete(s.watchers, currentUser)
+		}
+		close(ch)
+		s.watchersMu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// watched reports whether user has any watchers.
+func (s *service) watched(user users.UserSpec) bool {
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	return len(s.watchers[user]) > 0
+}
+
+// watchBuffer is the number of changes buffered for each watcher.
+const watchBuffer = 16
+
+// emit sends change c to all watchers of user.
+//
+// Watchers that aren't keeping up miss the change, rather than blocking the caller.
+// When a watcher's buffer is about to fill up, its last slot gets a ChangeResync,
+// and changes are dropped until the watcher drains the buffer. The watcher
+// lists notifications again after receiving the ChangeResync, so it doesn't
+// miss the dropped changes, which all happened before that.
+func (s *service) emit(user users.UserSpec, c notifications.Change) {
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	for ch, overflowed := range s.watchers[user] {
+		if overflowed {
+			// ChangeResync is the last buffered change,
+			// so it's been received once the buffer is empty.
+			if len(ch) > 0 {
+				continue
+			}
+			s.watchers[user][ch] = false
+		}
+		if len(ch) == cap(ch)-1 {
+			ch <- notifications.Change{Op: notifications.ChangeResync}
+			s.watchers[user][ch] = true
+			continue
+		}
+		ch <- c
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"dmitri.shuralyov.com/route/github"
 	githubv3 "github.com/google/go-github/github"
@@ -53,80 +56,103 @@ type service struct {
 
 	cacheMu sync.Mutex
 	cache   map[string]notifications.Notification
+
+	pollIntervalMu sync.Mutex
+	pollInterval   time.Duration // Most recent X-Poll-Interval from GitHub, or zero if not yet known.
 }
 
-func (s *service) List(ctx context.Context, opt notifications.ListOptions) (notifications.Notifications, error) {
+func (s *service) List(ctx context.Context, opt notifications.ListOptions) (notifications.Notifications, string, error) {
+	if opt.Saved {
+		return nil, "", fmt.Errorf("List: saved filter is %w by GitHub API", notifications.ErrNotSupported)
+	}
+
 	var ghNotifications []*githubv3.Notification
 
 	ghOpt := &githubv3.NotificationListOptions{
-		All:         opt.All,
-		ListOptions: githubv3.ListOptions{PerPage: 100},
+		All:           opt.All,
+		Participating: opt.Participating,
+		Since:         opt.Since,
+		Before:        opt.Before,
+		ListOptions:   githubv3.ListOptions{PerPage: 100},
 	}
-	switch opt.Repo {
-	case nil:
-		for {
-			ns, resp, err := ghListNotifications(ctx, s.clV3, ghOpt, false)
+	paginate := opt.PageSize > 0
+	if paginate {
+		// The cursor is a GitHub page number. Pages shift when notifications
+		// are marked read in between, so some may be listed twice or skipped.
+		ghOpt.PerPage = opt.PageSize
+		if opt.Cursor != "" {
+			page, err := strconv.Atoi(opt.Cursor)
 			if err != nil {
-				return nil, err
-			}
-			ghNotifications = append(ghNotifications, ns...)
-			if resp.NextPage == 0 {
-				break
+				return nil, "", fmt.Errorf("%w %q: %v", notifications.ErrInvalidCursor, opt.Cursor, err)
 			}
-			ghOpt.Page = resp.NextPage
+			ghOpt.Page = page
 		}
-	default:
-		repo, err := ghRepoSpec(*opt.Repo)
+	}
+	var nextCursor string
+	for {
+		ns, resp, err := s.listNotifications(ctx, opt.Repo, ghOpt)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		for {
-			ns, resp, err := ghListRepositoryNotifications(ctx, s.clV3, repo.Owner, repo.Repo, ghOpt, false)
-			if err != nil {
-				return nil, err
+		ghNotifications = append(ghNotifications, ns...)
+		s.setPollInterval(resp)
+		if paginate && resp.NextPage != 0 {
+			nextCursor = strconv.Itoa(resp.NextPage)
+		}
+		if paginate || resp.NextPage == 0 {
+			break
+		}
+		ghOpt.Page = resp.NextPage
+	}
+
+	// Apply filters that GitHub doesn't support on our end.
+	// When paginating, that may result in pages with fewer notifications than opt.PageSize.
+	// The actor filter is applied at the end, once actors are known.
+	if opt.ThreadType != "" || opt.Reason != "" || opt.Mentioned {
+		var filtered []*githubv3.Notification
+		for _, n := range ghNotifications {
+			if opt.ThreadType != "" && *n.Subject.Type != opt.ThreadType {
+				continue
 			}
-			ghNotifications = append(ghNotifications, ns...)
-			if resp.NextPage == 0 {
-				break
+			if opt.Reason != "" && *n.Reason != string(opt.Reason) {
+				continue
 			}
-			ghOpt.Page = resp.NextPage
+			if opt.Mentioned && *n.Reason != "mention" {
+				continue
+			}
+			filtered = append(filtered, n)
 		}
+		ghNotifications = filtered
 	}
 
 	type (
 		issueFragment struct {
-			State    githubv4.IssueState
-			Author   *githubV4Actor
-			Comments struct {
-				Nodes []struct {
-					Author     *githubV4Actor
-					DatabaseID uint64
-				}
-			} `graphql:"comments(last:1)"`
+			State     githubv4.IssueState
+			Author    *githubV4Actor
+			CreatedAt time.Time
+			BodyText  string
+			Comments  struct {
+				Nodes []eventNode
+			} `graphql:"comments(last:10)"`
 		}
 		prFragment struct {
-			State    githubv4.PullRequestState
-			Author   *githubV4Actor
-			Comments struct {
-				Nodes []struct {
-					Author     *githubV4Actor
-					DatabaseID uint64
-					CreatedAt  time.Time
-				}
-			} `graphql:"comments(last:1)"`
+			State     githubv4.PullRequestState
+			IsDraft   bool
+			Author    *githubV4Actor
+			CreatedAt time.Time
+			BodyText  string
+			Comments  struct {
+				Nodes []eventNode
+			} `graphql:"comments(last:10)"`
 			Reviews struct {
-				Nodes []struct {
-					Author     *githubV4Actor
-					DatabaseID uint64
-					CreatedAt  time.Time
-				}
-			} `graphql:"reviews(last:1)"`
+				Nodes []eventNode
+			} `graphql:"reviews(last:10)"`
 		}
 	)
 
 	var used map[string]bool // A set of used notification IDs, for unused cache eviction.
-	if opt.Repo == nil {
-		// Only evict cache if listing notifications across all repos.
+	if (opt == notifications.ListOptions{All: opt.All}) {
+		// Only evict cache if listing all notifications across all repos, without filters.
 		used = make(map[string]bool)
 	}
 	var fields []reflect.StructField
@@ -145,7 +171,7 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 		case "Issue":
 			rs, issueID, err := parseIssueSpec(*n.Subject.URL)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			fields = append(fields, reflect.StructField{
 				Tag:  graphqlTag(fmt.Sprintf("repository%d:repository(owner:%q,name:%q)", i, rs.Owner, rs.Repo)),
@@ -159,7 +185,7 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 		case "PullRequest":
 			rs, prID, err := parsePullRequestSpec(*n.Subject.URL)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			fields = append(fields, reflect.StructField{
 				Tag:  graphqlTag(fmt.Sprintf("repository%d:repository(owner:%q,name:%q)", i, rs.Owner, rs.Repo)),
@@ -176,7 +202,7 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 	if len(fields) > 0 {
 		err := s.clV4.Query(ctx, q.Addr().Interface(), nil)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 	if used != nil {
@@ -207,6 +233,7 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 			UpdatedAt:  *n.UpdatedAt,
 			Read:       !*n.Unread,
 
+			Reason:        notifications.Reason(*n.Reason),
 			Participating: *n.Reason != "subscribed", // According to https://developer.github.com/v3/activity/notifications/#notification-reasons, "subscribed" reason means "you're watching the repository", and all other reasons imply participation.
 			Mentioned:     *n.Reason == "mention",
 		}
@@ -217,62 +244,75 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 			// TODO: Don't do parseIssueSpec twice.
 			rs, issueID, err := parseIssueSpec(*n.Subject.URL)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
 			notification.ThreadID = issueID
 			switch issue.State {
 			case githubv4.IssueStateOpen:
-				notification.Icon = "issue-opened"
+				notification.Kind = notifications.KindIssueOpen
+				notification.State = notifications.SubjectStateOpen
 				notification.Color = notifications.RGB{R: 0x6c, G: 0xc6, B: 0x44} // Green.
 			case githubv4.IssueStateClosed:
-				notification.Icon = "issue-closed"
+				notification.Kind = notifications.KindIssueClosed
+				notification.State = notifications.SubjectStateClosed
 				notification.Color = notifications.RGB{R: 0xbd, G: 0x2c, B: 0x00} // Red.
 			}
-			switch len(issue.Comments.Nodes) {
+			switch c := issue.Comments.Nodes; len(c) {
 			case 0:
 				notification.Actor = ghActor(issue.Author)
 				notification.HTMLURL = s.rtr.IssueURL(ctx, rs.Owner, rs.Repo, issueID)
-			case 1:
-				notification.Actor = ghActor(issue.Comments.Nodes[0].Author)
-				notification.HTMLURL = s.rtr.IssueCommentURL(ctx, rs.Owner, rs.Repo, issueID, issue.Comments.Nodes[0].DatabaseID)
+				notification.Body = excerpt(issue.BodyText)
+			default:
+				comment := c[len(c)-1]
+				notification.Actor = ghActor(comment.Author)
+				notification.HTMLURL = s.rtr.IssueCommentURL(ctx, rs.Owner, rs.Repo, issueID, comment.DatabaseID)
+				notification.Body = excerpt(comment.BodyText)
 			}
+			notification.Actors, notification.EventCount = activity(eventNode{Author: issue.Author, CreatedAt: issue.CreatedAt}, issue.Comments.Nodes, n.LastReadAt)
 		case "PullRequest":
 			pr := q.FieldByName(fmt.Sprintf("Repository%d", i)).FieldByName("PullRequest").Interface().(prFragment)
 			// TODO: Don't do parsePullRequestSpec twice.
 			rs, prID, err := parsePullRequestSpec(*n.Subject.URL)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
 			notification.ThreadID = prID
-			notification.Icon = "git-pull-request"
 			switch pr.State {
 			case githubv4.PullRequestStateOpen:
+				notification.Kind = notifications.KindPROpen
+				notification.State = notifications.SubjectStateOpen
+				if pr.IsDraft {
+					notification.State = notifications.SubjectStateDraft
+				}
 				notification.Color = notifications.RGB{R: 0x6c, G: 0xc6, B: 0x44} // Green.
 			case githubv4.PullRequestStateClosed:
+				notification.Kind = notifications.KindPRClosed
+				notification.State = notifications.SubjectStateClosed
 				notification.Color = notifications.RGB{R: 0xbd, G: 0x2c, B: 0x00} // Red.
 			case githubv4.PullRequestStateMerged:
+				notification.Kind = notifications.KindPRMerged
+				notification.State = notifications.SubjectStateMerged
 				notification.Color = notifications.RGB{R: 0x6e, G: 0x54, B: 0x94} // Purple.
 			}
 			switch c, r := pr.Comments.Nodes, pr.Reviews.Nodes; {
 			case len(c) == 0 && len(r) == 0:
 				notification.Actor = ghActor(pr.Author)
 				notification.HTMLURL = s.rtr.PullRequestURL(ctx, rs.Owner, rs.Repo, prID)
-			case len(c) == 1 && len(r) == 0:
-				notification.Actor = ghActor(c[0].Author)
-				notification.HTMLURL = s.rtr.PullRequestCommentURL(ctx, rs.Owner, rs.Repo, prID, c[0].DatabaseID)
-			case len(c) == 0 && len(r) == 1:
-				notification.Actor = ghActor(r[0].Author)
-				notification.HTMLURL = s.rtr.PullRequestReviewURL(ctx, rs.Owner, rs.Repo, prID, r[0].DatabaseID)
-			case len(c) == 1 && len(r) == 1:
+				notification.Body = excerpt(pr.BodyText)
+			case len(r) == 0 || len(c) > 0 && c[len(c)-1].CreatedAt.After(r[len(r)-1].CreatedAt):
 				// Use the later of the two.
-				if c[0].CreatedAt.After(r[0].CreatedAt) {
-					notification.Actor = ghActor(c[0].Author)
-					notification.HTMLURL = s.rtr.PullRequestCommentURL(ctx, rs.Owner, rs.Repo, prID, c[0].DatabaseID)
-				} else {
-					notification.Actor = ghActor(r[0].Author)
-					notification.HTMLURL = s.rtr.PullRequestReviewURL(ctx, rs.Owner, rs.Repo, prID, r[0].DatabaseID)
-				}
+				comment := c[len(c)-1]
+				notification.Actor = ghActor(comment.Author)
+				notification.HTMLURL = s.rtr.PullRequestCommentURL(ctx, rs.Owner, rs.Repo, prID, comment.DatabaseID)
+				notification.Body = excerpt(comment.BodyText)
+			default:
+				review := r[len(r)-1]
+				notification.Actor = ghActor(review.Author)
+				notification.HTMLURL = s.rtr.PullRequestReviewURL(ctx, rs.Owner, rs.Repo, prID, review.DatabaseID)
+				notification.Body = excerpt(review.BodyText)
 			}
+			events := append(append([]eventNode(nil), pr.Comments.Nodes...), pr.Reviews.Nodes...)
+			notification.Actors, notification.EventCount = activity(eventNode{Author: pr.Author, CreatedAt: pr.CreatedAt}, events, n.LastReadAt)
 		case "Commit":
 			// getNotificationActor makes a single API call. It's relatively slow/expensive
 			// because it happens in the ghNotifications loop.
@@ -280,38 +320,42 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 
 			id, err := strconv.ParseUint(*n.ID, 10, 64)
 			if err != nil {
-				return ns, fmt.Errorf("notifications/githubapi: failed to parse Commit notification ID %q to uint64: %v", *n.ID, err)
+				return ns, "", fmt.Errorf("notifications/githubapi: failed to parse Commit notification ID %q to uint64: %v", *n.ID, err)
 			}
 			notification.ThreadID = id
-			notification.Icon = "git-commit"
+			notification.Kind = notifications.KindCommit
 			notification.Color = notifications.RGB{R: 0x76, G: 0x76, B: 0x76} // Gray.
 			notification.Actor, err = s.getNotificationActor(ctx, *n.Subject)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
 			notification.HTMLURL, err = getCommitURL(*n.Subject)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
 		case "Release":
-			// getNotificationActor and getReleaseURL make two API calls. It's relatively slow/expensive
+			// getNotificationActor and getRelease make two API calls. It's relatively slow/expensive
 			// because it happens in the ghNotifications loop.
 			// TODO: Fetch using GraphQL.
 
 			id, err := strconv.ParseUint(*n.ID, 10, 64)
 			if err != nil {
-				return ns, fmt.Errorf("notifications/githubapi: failed to parse Release notification ID %q to uint64: %v", *n.ID, err)
+				return ns, "", fmt.Errorf("notifications/githubapi: failed to parse Release notification ID %q to uint64: %v", *n.ID, err)
 			}
 			notification.ThreadID = id
-			notification.Icon = "tag"
+			notification.Kind = notifications.KindRelease
 			notification.Color = notifications.RGB{R: 0x76, G: 0x76, B: 0x76} // Gray.
 			notification.Actor, err = s.getNotificationActor(ctx, *n.Subject)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
-			notification.HTMLURL, err = s.getReleaseURL(ctx, *n.Subject.URL)
+			var prerelease bool
+			notification.HTMLURL, prerelease, err = s.getRelease(ctx, *n.Subject.URL)
 			if err != nil {
-				return ns, err
+				return ns, "", err
+			}
+			if prerelease {
+				notification.State = notifications.SubjectStatePrerelease
 			}
 		case "RepositoryInvitation":
 			// getNotificationActor makes a single API call. It's relatively slow/expensive
@@ -320,19 +364,25 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 
 			id, err := strconv.ParseUint(*n.ID, 10, 64)
 			if err != nil {
-				return ns, fmt.Errorf("notifications/githubapi: failed to parse RepositoryInvitation notification ID %q to uint64: %v", *n.ID, err)
+				return ns, "", fmt.Errorf("notifications/githubapi: failed to parse RepositoryInvitation notification ID %q to uint64: %v", *n.ID, err)
 			}
 			notification.ThreadID = id
-			notification.Icon = "mail"
+			notification.Kind = notifications.KindInvitation
 			notification.Color = notifications.RGB{R: 0x76, G: 0x76, B: 0x76} // Gray.
 			notification.Actor, err = s.getNotificationActor(ctx, *n.Subject)
 			if err != nil {
-				return ns, err
+				return ns, "", err
 			}
 			notification.HTMLURL = getRepositoryInvitationURL(*n.Repository.FullName)
 		default:
 			log.Printf("unsupported *n.Subject.Type: %q\n", *n.Subject.Type)
 		}
+		notification.Icon = notifications.OcticonID(notifications.Octicons.Icon(notification.Kind, ""))
+		if len(notification.Actors) == 0 {
+			// Only the event that caused the notification is known.
+			notification.Actors = []users.User{notification.Actor}
+			notification.EventCount = 1
+		}
 
 		s.cacheMu.Lock()
 		s.cache[*n.ID] = notification
@@ -340,7 +390,19 @@ func (s *service) List(ctx context.Context, opt notifications.ListOptions) (noti
 
 		ns = append(ns, notification)
 	}
-	return ns, nil
+
+	if opt.Actor != nil {
+		var filtered []notifications.Notification
+		for _, n := range ns {
+			if n.Actor.UserSpec != *opt.Actor {
+				continue
+			}
+			filtered = append(filtered, n)
+		}
+		ns = filtered
+	}
+
+	return ns, nextCursor, nil
 }
 
 // graphqlTag returns a `graphql:"{value}"` struct field tag string.
@@ -348,27 +410,156 @@ func graphqlTag(value string) reflect.StructTag {
 	return reflect.StructTag(strconv.AppendQuote([]byte("graphql:"), value))
 }
 
-func (s *service) Count(ctx context.Context, opt interface{}) (uint64, error) {
-	ghOpt := &githubv3.NotificationListOptions{ListOptions: githubv3.ListOptions{PerPage: 1}}
-	ghNotifications, resp, err := ghListNotifications(ctx, s.clV3, ghOpt, false)
-	if err != nil {
-		return 0, err
+func (s *service) Count(ctx context.Context, opt notifications.CountOptions) (uint64, error) {
+	if opt.ThreadType == "" && !opt.Mentioned {
+		// GitHub can filter by all remaining options. Ask for 1 notification per page,
+		// so that the number of the last page is the count.
+		ghOpt := &githubv3.NotificationListOptions{
+			Participating: opt.Participating,
+			ListOptions:   githubv3.ListOptions{PerPage: 1},
+		}
+		ghNotifications, resp, err := s.listNotifications(ctx, opt.Repo, ghOpt)
+		if err != nil {
+			return 0, err
+		}
+		if resp.LastPage != 0 {
+			return uint64(resp.LastPage), nil
+		} else {
+			return uint64(len(ghNotifications)), nil
+		}
 	}
-	if resp.LastPage != 0 {
-		return uint64(resp.LastPage), nil
-	} else {
-		return uint64(len(ghNotifications)), nil
+
+	// Filter by thread type and mention on our end.
+	ghOpt := &githubv3.NotificationListOptions{
+		Participating: opt.Participating,
+		ListOptions:   githubv3.ListOptions{PerPage: 100},
+	}
+	var count uint64
+	for {
+		ns, resp, err := s.listNotifications(ctx, opt.Repo, ghOpt)
+		if err != nil {
+			return 0, err
+		}
+		for _, n := range ns {
+			if opt.ThreadType != "" && *n.Subject.Type != opt.ThreadType {
+				continue
+			}
+			if opt.Mentioned && *n.Reason != "mention" {
+				continue
+			}
+			count++
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		ghOpt.Page = resp.NextPage
 	}
+	return count, nil
+}
+
+// listNotifications lists a single page of notifications without using cache,
+// either across all repos if repo is nil, or only from repo otherwise.
+func (s *service) listNotifications(ctx context.Context, repo *notifications.RepoSpec, opt *githubv3.NotificationListOptions) ([]*githubv3.Notification, *githubv3.Response, error) {
+	if repo == nil {
+		return ghListNotifications(ctx, s.clV3, opt, false)
+	}
+	rs, err := ghRepoSpec(*repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ghListRepositoryNotifications(ctx, s.clV3, rs.Owner, rs.Repo, opt, false)
 }
 
 func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64) error {
-	switch threadType {
-	case "Commit", "Release", "RepositoryInvitation":
-		_, err := s.clV3.Activity.MarkThreadRead(ctx, strconv.FormatUint(threadID, 10))
+	id, err := s.notificationID(ctx, rs, threadType, threadID)
+	if err != nil {
+		return fmt.Errorf("MarkRead: %v", err)
+	}
+	if id == "" {
+		// Didn't find any matching notification to mark read.
+		// Nothing to do.
+		return nil
+	}
+	_, err = s.clV3.Activity.MarkThreadRead(ctx, id)
+	if err != nil {
+		return fmt.Errorf("MarkRead: failed to MarkThreadRead: %v", err)
+	}
+	return nil
+}
+
+func (s *service) MarkReadBatch(ctx context.Context, threads []notifications.ThreadRef) error {
+	// Validate all threads before making any changes, so that an invalid
+	// thread doesn't leave the batch partially marked read.
+	// Threads whose ID is the notification ID are marked read directly.
+	// Group the rest by repo, so that notifications of each repo are listed once.
+	var (
+		direct []notifications.ThreadRef
+		repos  []notifications.RepoSpec
+		byRepo = make(map[notifications.RepoSpec][]notifications.ThreadRef)
+	)
+	for _, t := range threads {
+		switch t.ThreadType {
+		case "Commit", "Release", "RepositoryInvitation":
+			direct = append(direct, t)
+		case "Issue", "PullRequest":
+			if _, ok := byRepo[t.RepoSpec]; !ok {
+				_, err := ghRepoSpec(t.RepoSpec)
+				if err != nil {
+					return err
+				}
+				repos = append(repos, t.RepoSpec)
+			}
+			byRepo[t.RepoSpec] = append(byRepo[t.RepoSpec], t)
+		default:
+			return fmt.Errorf("MarkReadBatch: unsupported threadType: %v", t.ThreadType)
+		}
+	}
+
+	for _, t := range direct {
+		_, err := s.clV3.Activity.MarkThreadRead(ctx, strconv.FormatUint(t.ThreadID, 10))
 		if err != nil {
-			return fmt.Errorf("MarkRead: failed to MarkThreadRead: %v", err)
+			return fmt.Errorf("MarkReadBatch: failed to MarkThreadRead: %v", err)
 		}
-		return nil
+	}
+	for _, rs := range repos {
+		repo, _ := ghRepoSpec(rs) // Validated above.
+		var ns []*githubv3.Notification
+		ghOpt := &githubv3.NotificationListOptions{ListOptions: githubv3.ListOptions{PerPage: 100}}
+		for {
+			page, resp, err := ghListRepositoryNotifications(ctx, s.clV3, repo.Owner, repo.Repo, ghOpt, false)
+			if err != nil {
+				return fmt.Errorf("MarkReadBatch: failed to ListRepositoryNotifications: %v", err)
+			}
+			ns = append(ns, page...)
+			if resp.NextPage == 0 {
+				break
+			}
+			ghOpt.Page = resp.NextPage
+		}
+		for _, t := range byRepo[rs] {
+			notif, err := findNotification(ns, t.ThreadType, t.ThreadID)
+			if err != nil {
+				return fmt.Errorf("MarkReadBatch: %v", err)
+			} else if notif == nil {
+				// Didn't find any matching notification to mark read.
+				continue
+			}
+			_, err = s.clV3.Activity.MarkThreadRead(ctx, *notif.ID)
+			if err != nil {
+				return fmt.Errorf("MarkReadBatch: failed to MarkThreadRead: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// notificationID returns the ID of the GitHub notification thread that matches
+// the specified thread, or empty string if no matching notification is found.
+func (s *service) notificationID(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64) (string, error) {
+	switch threadType {
+	case "Commit", "Release", "RepositoryInvitation":
+		// For these thread types, thread ID is the notification ID.
+		return strconv.FormatUint(threadID, 10), nil
 	case "Issue", "PullRequest":
 		// For these thread types, thread ID is not the notification ID, but rather the
 		// issue/PR number. We need to find a matching notification, if any exists.
@@ -381,24 +572,24 @@ func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threa
 		//         issuesapp.augmentUnread works correctly. But maybe if we can store it in another
 		//         field...
 	default:
-		return fmt.Errorf("MarkRead: unsupported threadType: %v", threadType)
+		return "", fmt.Errorf("unsupported threadType: %v", threadType)
 	}
 
 	repo, err := ghRepoSpec(rs)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var alsoLookWithoutCache bool
 
 	// First, iterate over all pages of notifications, looking for the specified notification.
 	// It's okay to use with-cache client here, because we don't mind seeing read notifications
-	// for the purposes of MarkRead. They'll be skipped if the notification ID doesn't match.
+	// for the purposes of finding one. They'll be skipped if the notification ID doesn't match.
 	ghOpt := &githubv3.NotificationListOptions{ListOptions: githubv3.ListOptions{PerPage: 100}}
 	for {
 		cached, resp, err := ghListRepositoryNotifications(ctx, s.clV3, repo.Owner, repo.Repo, ghOpt, true)
 		if err != nil {
-			return fmt.Errorf("failed to ListRepositoryNotifications: %v", err)
+			return "", fmt.Errorf("failed to ListRepositoryNotifications: %v", err)
 		}
 		if _, ok := resp.Response.Header[httpcache.XFromCache]; ok {
 			// If and only if any of the responses come from cache,
@@ -406,10 +597,10 @@ func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threa
 			alsoLookWithoutCache = true
 		}
 		if notif, err := findNotification(cached, threadType, threadID); err != nil {
-			return err
+			return "", err
 		} else if notif != nil {
-			// Found a matching notification, mark it read.
-			return s.markRead(ctx, notif)
+			// Found a matching notification.
+			return *notif.ID, nil
 		}
 		if resp.NextPage == 0 {
 			break
@@ -418,7 +609,7 @@ func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threa
 	}
 
 	// However, there are sometimes caching issues causing stale repository notifications
-	// to be retrieved from cache, and a legitimate existing notification is not marked read.
+	// to be retrieved from cache, and a legitimate existing notification is not found.
 	// So fall back to skipping cache, if we can't find a notification and the response
 	// we got was from cache (rather than origin server).
 	if alsoLookWithoutCache {
@@ -426,14 +617,14 @@ func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threa
 		for {
 			uncached, resp, err := ghListRepositoryNotifications(ctx, s.clV3, repo.Owner, repo.Repo, ghOpt, false)
 			if err != nil {
-				return fmt.Errorf("failed to ListRepositoryNotifications: %v", err)
+				return "", fmt.Errorf("failed to ListRepositoryNotifications: %v", err)
 			}
 			if notif, err := findNotification(uncached, threadType, threadID); err != nil {
-				return err
+				return "", err
 			} else if notif != nil {
-				// Found a matching notification, mark it read.
-				log.Printf(`MarkRead: did not find notification %s/%s %s %d within cached notifications, but did find within uncached ones`, repo.Owner, repo.Repo, threadType, threadID)
-				return s.markRead(ctx, notif)
+				// Found a matching notification.
+				log.Printf(`did not find notification %s/%s %s %d within cached notifications, but did find within uncached ones`, repo.Owner, repo.Repo, threadType, threadID)
+				return *notif.ID, nil
 			}
 			if resp.NextPage == 0 {
 				break
@@ -442,17 +633,8 @@ func (s *service) MarkRead(ctx context.Context, rs notifications.RepoSpec, threa
 		}
 	}
 
-	// Didn't find any matching notification to mark read.
-	// Nothing to do.
-	return nil
-}
-
-func (s *service) markRead(ctx context.Context, n *githubv3.Notification) error {
-	_, err := s.clV3.Activity.MarkThreadRead(ctx, *n.ID)
-	if err != nil {
-		return fmt.Errorf("failed to MarkThreadRead: %v", err)
-	}
-	return nil
+	// Didn't find any matching notification.
+	return "", nil
 }
 
 // findNotification tries to find a notification that matches
@@ -493,18 +675,60 @@ func findNotification(ns []*githubv3.Notification, threadType string, threadID u
 	return nil, nil
 }
 
-func (s *service) MarkAllRead(ctx context.Context, rs notifications.RepoSpec) error {
-	repo, err := ghRepoSpec(rs)
+func (s *service) MarkAllRead(ctx context.Context, opt notifications.MarkAllReadOptions) error {
+	lastReadAt := opt.LastReadAt
+	if lastReadAt.IsZero() {
+		lastReadAt = time.Now()
+	}
+	if opt.Repo == nil {
+		_, err := s.clV3.Activity.MarkNotificationsRead(ctx, lastReadAt)
+		if err != nil {
+			return fmt.Errorf("MarkAllRead: failed to MarkNotificationsRead: %v", err)
+		}
+		return nil
+	}
+
+	repo, err := ghRepoSpec(*opt.Repo)
 	if err != nil {
 		return err
 	}
-	_, err = s.clV3.Activity.MarkRepositoryNotificationsRead(ctx, repo.Owner, repo.Repo, time.Now())
+	_, err = s.clV3.Activity.MarkRepositoryNotificationsRead(ctx, repo.Owner, repo.Repo, lastReadAt)
 	if err != nil {
 		return fmt.Errorf("MarkAllRead: failed to MarkRepositoryNotificationsRead: %v", err)
 	}
 	return nil
 }
 
+func (s *service) MarkUnread(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64) error {
+	return fmt.Errorf("MarkUnread: %w by GitHub API", notifications.ErrNotSupported)
+}
+
+// Delete marks the notification of the specified thread as done.
+func (s *service) Delete(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64) error {
+	id, err := s.notificationID(ctx, rs, threadType, threadID)
+	if err != nil {
+		return fmt.Errorf("Delete: %v", err)
+	}
+	if id == "" {
+		// Didn't find any matching notification to mark done.
+		// Nothing to do.
+		return nil
+	}
+	_, err = ghMarkThreadDone(ctx, s.clV3, id)
+	if err != nil {
+		return fmt.Errorf("Delete: failed to MarkThreadDone: %v", err)
+	}
+	return nil
+}
+
+func (s *service) Snooze(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64, until time.Time) error {
+	return fmt.Errorf("Snooze: %w by GitHub API", notifications.ErrNotSupported)
+}
+
+func (s *service) SetSaved(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64, saved bool) error {
+	return fmt.Errorf("SetSaved: %w by GitHub API", notifications.ErrNotSupported)
+}
+
 func (s *service) Notify(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, op notifications.NotificationRequest) error {
 	// Nothing to do. GitHub takes care of this on their end, even when creating comments/issues via API.
 	return nil
@@ -515,6 +739,112 @@ func (s *service) Subscribe(ctx context.Context, repo notifications.RepoSpec, th
 	return nil
 }
 
+// Unsubscribe unsubscribes the authenticated user from the specified thread or repo.
+// GitHub only allows managing subscriptions of the authenticated user, so subscribers are not used.
+func (s *service) Unsubscribe(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	if threadType == "" && threadID == 0 {
+		repo, err := ghRepoSpec(rs)
+		if err != nil {
+			return err
+		}
+		_, err = s.clV3.Activity.DeleteRepositorySubscription(ctx, repo.Owner, repo.Repo)
+		if err != nil {
+			return fmt.Errorf("Unsubscribe: failed to DeleteRepositorySubscription: %v", err)
+		}
+		return nil
+	}
+	id, err := s.notificationID(ctx, rs, threadType, threadID)
+	if err != nil {
+		return fmt.Errorf("Unsubscribe: %v", err)
+	}
+	if id == "" {
+		// Didn't find any matching notification, so there's no thread subscription to delete.
+		return nil
+	}
+	_, err = s.clV3.Activity.DeleteThreadSubscription(ctx, id)
+	if err != nil {
+		return fmt.Errorf("Unsubscribe: failed to DeleteThreadSubscription: %v", err)
+	}
+	return nil
+}
+
+var _ notifications.RepoWatchService = &service{}
+
+// SetRepoWatch sets the level at which the authenticated user watches the specified repo.
+// GitHub only allows managing subscriptions of the authenticated user, so subscribers are not used.
+// WatchCustom is not supported by GitHub API.
+func (s *service) SetRepoWatch(ctx context.Context, rs notifications.RepoSpec, subscribers []users.UserSpec, w notifications.RepoWatch) error {
+	repo, err := ghRepoSpec(rs)
+	if err != nil {
+		return err
+	}
+	switch w.Level {
+	case notifications.WatchAll:
+		_, _, err = s.clV3.Activity.SetRepositorySubscription(ctx, repo.Owner, repo.Repo, &githubv3.Subscription{Subscribed: githubv3.Bool(true)})
+	case notifications.WatchIgnore:
+		_, _, err = s.clV3.Activity.SetRepositorySubscription(ctx, repo.Owner, repo.Repo, &githubv3.Subscription{Ignored: githubv3.Bool(true)})
+	case notifications.WatchParticipating:
+		_, err = s.clV3.Activity.DeleteRepositorySubscription(ctx, repo.Owner, repo.Repo)
+	default:
+		return fmt.Errorf("SetRepoWatch: watch level %q: %w by GitHub API", w.Level, notifications.ErrNotSupported)
+	}
+	if err != nil {
+		return fmt.Errorf("SetRepoWatch: failed to set repository subscription: %v", err)
+	}
+	return nil
+}
+
+// GetRepoWatch returns the level at which the authenticated user watches the specified repo.
+// user must be the authenticated GitHub user, otherwise os.ErrPermission is returned.
+func (s *service) GetRepoWatch(ctx context.Context, rs notifications.RepoSpec, user users.UserSpec) (notifications.RepoWatch, error) {
+	repo, err := ghRepoSpec(rs)
+	if err != nil {
+		return notifications.RepoWatch{}, err
+	}
+	// GitHub only reports the subscription of the authenticated user.
+	authenticated, _, err := s.clV3.Users.Get(ctx, "")
+	if err != nil {
+		return notifications.RepoWatch{}, fmt.Errorf("GetRepoWatch: failed to get authenticated user: %v", err)
+	}
+	if user != (users.UserSpec{ID: uint64(authenticated.GetID()), Domain: "github.com"}) {
+		return notifications.RepoWatch{}, os.ErrPermission
+	}
+	sub, _, err := s.clV3.Activity.GetRepositorySubscription(ctx, repo.Owner, repo.Repo)
+	if err != nil {
+		return notifications.RepoWatch{}, fmt.Errorf("GetRepoWatch: failed to GetRepositorySubscription: %v", err)
+	}
+	switch {
+	case sub.GetIgnored():
+		return notifications.RepoWatch{Level: notifications.WatchIgnore}, nil
+	case sub.GetSubscribed():
+		return notifications.RepoWatch{Level: notifications.WatchAll}, nil
+	default:
+		// Not watching the repo, which GetRepositorySubscription reports as nil sub.
+		return notifications.RepoWatch{Level: notifications.WatchParticipating}, nil
+	}
+}
+
+// Ignore makes the authenticated user ignore the specified thread.
+// GitHub only allows managing subscriptions of the authenticated user, so subscribers are not used.
+func (s *service) Ignore(ctx context.Context, rs notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	if threadType == "" && threadID == 0 {
+		return fmt.Errorf("Ignore: threadType and threadID must be non-zero")
+	}
+	id, err := s.notificationID(ctx, rs, threadType, threadID)
+	if err != nil {
+		return fmt.Errorf("Ignore: %v", err)
+	}
+	if id == "" {
+		// GitHub only has thread subscriptions for threads with notifications.
+		return fmt.Errorf("Ignore: no notification found for %s %s %d", rs, threadType, threadID)
+	}
+	_, _, err = s.clV3.Activity.SetThreadSubscription(ctx, id, &githubv3.Subscription{Ignored: githubv3.Bool(true)})
+	if err != nil {
+		return fmt.Errorf("Ignore: failed to SetThreadSubscription: %v", err)
+	}
+	return nil
+}
+
 // getNotificationActor tries to follow the LatestCommentURL, if not-nil,
 // to fetch an object that contains a User or Author, who is taken to be
 // the actor that triggered the notification. It returns an error only if
@@ -561,19 +891,19 @@ func getCommitURL(subject githubv3.NotificationSubject) (string, error) {
 	return fmt.Sprintf("https://github.com/%s/%s/commit/%s", rs.Owner, rs.Repo, commit), nil
 }
 
-// getReleaseURL makes a single API call to get the Release HTMLURL
-// from the given releaseAPIURL.
-func (s *service) getReleaseURL(ctx context.Context, releaseAPIURL string) (string, error) {
+// getRelease makes a single API call to get the Release HTMLURL,
+// and whether it's a pre-release, from the given releaseAPIURL.
+func (s *service) getRelease(ctx context.Context, releaseAPIURL string) (htmlURL string, prerelease bool, _ error) {
 	req, err := s.clV3.NewRequest("GET", releaseAPIURL, nil)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	var rr githubv3.RepositoryRelease
 	_, err = s.clV3.Do(ctx, req, &rr)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
-	return *rr.HTMLURL, nil
+	return *rr.HTMLURL, rr.GetPrerelease(), nil
 }
 
 func getRepositoryInvitationURL(fullName string) string {
@@ -690,6 +1020,49 @@ func avatarURLSize(avatarURL string, size int) string {
 	return u.String()
 }
 
+// eventNode is an event in an issue or pull request thread,
+// such as a comment or review.
+type eventNode struct {
+	Author     *githubV4Actor
+	DatabaseID uint64
+	CreatedAt  time.Time
+	BodyText   string
+}
+
+// activity returns distinct authors of thread events that happened after lastReadAt,
+// most recent first, and the number of such events. opened is the event of
+// the thread being opened, and events are its most recent comments and reviews.
+// A nil lastReadAt means the thread was never read.
+func activity(opened eventNode, events []eventNode, lastReadAt *time.Time) (actors []users.User, count int) {
+	events = append([]eventNode{opened}, events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+	seen := make(map[users.UserSpec]bool)
+	for _, e := range events {
+		if lastReadAt != nil && !e.CreatedAt.After(*lastReadAt) {
+			break
+		}
+		count++
+		actor := ghActor(e.Author)
+		if seen[actor.UserSpec] {
+			continue
+		}
+		seen[actor.UserSpec] = true
+		actors = append(actors, actor)
+	}
+	return actors, count
+}
+
+// excerpt returns the beginning of body, suitable for a preview.
+// It's truncated to at most 300 characters, ending with an ellipsis if so.
+func excerpt(body string) string {
+	const max = 300
+	body = strings.TrimSpace(body)
+	if utf8.RuneCountInString(body) <= max {
+		return body
+	}
+	return string([]rune(body)[:max-1]) + "…"
+}
+
 // ghost is https://github.com/ghost, a replacement for deleted users.
 var ghost = users.User{
 	UserSpec: users.UserSpec{
@@ -747,6 +1120,19 @@ func ghListRepositoryNotifications(ctx context.Context, cl *githubv3.Client, own
 	return notifications, resp, err
 }
 
+// ghMarkThreadDone marks the notification thread with id as done.
+// It's not yet available in githubv3.Client.Activity.
+//
+// GitHub API docs: https://docs.github.com/en/rest/activity/notifications#mark-a-thread-as-done
+func ghMarkThreadDone(ctx context.Context, cl *githubv3.Client, id string) (*githubv3.Response, error) {
+	u := fmt.Sprintf("notifications/threads/%v", id)
+	req, err := cl.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cl.Do(ctx, req, nil)
+}
+
 // ghAddOptions adds the parameters in opt as URL query parameters to s.
 // opt must be a struct (or a pointer to one) whose fields may contain "url" tags.
 func ghAddOptions(s string, opt interface{}) (string, error) {
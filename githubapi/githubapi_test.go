@@ -1,9 +1,21 @@
 package githubapi
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/github"
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
 )
 
 func TestGetCommitURL(t *testing.T) {
@@ -27,3 +39,232 @@ func TestAvatarURLSize(t *testing.T) {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestActivity(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	actor := func(id uint64) *githubV4Actor {
+		a := &githubV4Actor{}
+		a.User.DatabaseID = id
+		return a
+	}
+	opened := eventNode{Author: actor(1), CreatedAt: t0}
+	events := []eventNode{
+		{Author: actor(2), CreatedAt: t0.Add(1 * time.Minute)},
+		{Author: actor(3), CreatedAt: t0.Add(2 * time.Minute)},
+		{Author: actor(2), CreatedAt: t0.Add(3 * time.Minute)},
+	}
+	for _, tc := range []struct {
+		lastReadAt *time.Time
+		wantActors string
+		wantCount  int
+	}{
+		{nil, "[2 3 1]", 4},
+		{&t0, "[2 3]", 3},
+		{timePtr(t0.Add(2 * time.Minute)), "[2]", 1},
+		{timePtr(t0.Add(time.Hour)), "[]", 0},
+	} {
+		actors, count := activity(opened, events, tc.lastReadAt)
+		var ids []uint64
+		for _, a := range actors {
+			ids = append(ids, a.ID)
+		}
+		if got := fmt.Sprint(ids); got != tc.wantActors {
+			t.Errorf("got actors %v, want %v", got, tc.wantActors)
+		}
+		if count != tc.wantCount {
+			t.Errorf("got count %d, want %d", count, tc.wantCount)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func TestExcerpt(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"  Looks good to me.\n", "Looks good to me."},
+		{strings.Repeat("é", 300), strings.Repeat("é", 300)},
+		{strings.Repeat("é", 301), strings.Repeat("é", 299) + "…"},
+	} {
+		if got := excerpt(tc.in); got != tc.want {
+			t.Errorf("excerpt(%q): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := notifications.RepoSpec{URI: "github.com/owner/name"}
+	prev := notifications.Notifications{
+		{RepoSpec: repo, ThreadType: "Issue", ThreadID: 1, UpdatedAt: t0},
+		{RepoSpec: repo, ThreadType: "Issue", ThreadID: 2, UpdatedAt: t0},
+		{RepoSpec: repo, ThreadType: "PullRequest", ThreadID: 1, UpdatedAt: t0},
+	}
+	cur := notifications.Notifications{
+		{RepoSpec: repo, ThreadType: "Issue", ThreadID: 1, UpdatedAt: t0},
+		{RepoSpec: repo, ThreadType: "Issue", ThreadID: 2, UpdatedAt: t0.Add(time.Minute)},
+		{RepoSpec: repo, ThreadType: "Issue", ThreadID: 3, UpdatedAt: t0},
+	}
+	var got []string
+	for _, c := range changes(prev, cur) {
+		got = append(got, fmt.Sprintf("%s %s %d", c.Op, c.ThreadType, c.ThreadID))
+	}
+	want := []string{"updated Issue 2", "created Issue 3", "read PullRequest 1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	var got []string // Requests made, with their last_read_at.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			LastReadAt time.Time `json:"last_read_at"`
+		}
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", req.Method, req.URL.Path, body.LastReadAt.Format(time.RFC3339)))
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	defer ts.Close()
+	clV3 := github.NewClient(nil)
+	clV3.BaseURL, _ = url.Parse(ts.URL + "/")
+	s := NewService(clV3, nil, nil)
+
+	lastReadAt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{
+		Repo:       &notifications.RepoSpec{URI: "github.com/owner/name"},
+		LastReadAt: lastReadAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.MarkAllRead(context.Background(), notifications.MarkAllReadOptions{LastReadAt: lastReadAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"PUT /repos/owner/name/notifications 2018-01-01T00:00:00Z",
+		"PUT /notifications 2018-01-01T00:00:00Z",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkReadBatchInvalid(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	defer ts.Close()
+	clV3 := github.NewClient(nil)
+	clV3.BaseURL, _ = url.Parse(ts.URL + "/")
+	s := NewService(clV3, nil, nil)
+
+	repo := notifications.RepoSpec{URI: "github.com/owner/name"}
+	for _, threads := range [][]notifications.ThreadRef{
+		{{RepoSpec: repo, ThreadType: "Commit", ThreadID: 1}, {RepoSpec: repo, ThreadType: "Unknown", ThreadID: 2}},
+		{{RepoSpec: repo, ThreadType: "Release", ThreadID: 1}, {RepoSpec: notifications.RepoSpec{URI: "example.org/repo"}, ThreadType: "Issue", ThreadID: 2}},
+	} {
+		err := s.MarkReadBatch(context.Background(), threads)
+		if err == nil {
+			t.Errorf("%+v: want error, got nil", threads)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("got %d requests, want none before all threads are validated", requests)
+	}
+}
+
+func TestGetRepoWatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/user":
+			fmt.Fprint(w, `{"id": 1, "login": "gopher"}`)
+		case "/repos/owner/name/subscription":
+			fmt.Fprint(w, `{"subscribed": true}`)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer ts.Close()
+	clV3 := github.NewClient(nil)
+	clV3.BaseURL, _ = url.Parse(ts.URL + "/")
+	rw := NewService(clV3, nil, nil).(notifications.RepoWatchService)
+	repo := notifications.RepoSpec{URI: "github.com/owner/name"}
+
+	w, err := rw.GetRepoWatch(context.Background(), repo, users.UserSpec{ID: 1, Domain: "github.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Level != notifications.WatchAll {
+		t.Errorf("got watch level %q, want %q", w.Level, notifications.WatchAll)
+	}
+
+	// Other users' watch levels are off limits.
+	_, err = rw.GetRepoWatch(context.Background(), repo, users.UserSpec{ID: 2, Domain: "github.com"})
+	if !os.IsPermission(err) {
+		t.Errorf("want permission error, got %v", err)
+	}
+}
+
+func TestNotificationsModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2018 00:00:00 GMT"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Poll-Interval", "30")
+		if req.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		fmt.Fprint(w, "[]")
+	}))
+	defer ts.Close()
+	clV3 := github.NewClient(nil)
+	clV3.BaseURL, _ = url.Parse(ts.URL + "/")
+	s := NewService(clV3, nil, nil).(*service)
+
+	modified, lm, err := s.notificationsModified(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || lm != lastModified {
+		t.Errorf("initial: got modified %v, Last-Modified %q; want true, %q", modified, lm, lastModified)
+	}
+	modified, lm, err = s.notificationsModified(context.Background(), lm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified || lm != lastModified {
+		t.Errorf("unchanged: got modified %v, Last-Modified %q; want false, %q", modified, lm, lastModified)
+	}
+	if got, want := s.getPollInterval(), 30*time.Second; got != want {
+		t.Errorf("got poll interval %v, want %v", got, want)
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	repo := notifications.RepoSpec{URI: "github.com/owner/name"}
+	for name, err := range map[string]error{
+		"MarkUnread":   s.MarkUnread(context.Background(), repo, "Issue", 1),
+		"Snooze":       s.Snooze(context.Background(), repo, "Issue", 1, time.Now()),
+		"SetSaved":     s.SetSaved(context.Background(), repo, "Issue", 1, true),
+		"List":         listErr(s.List(context.Background(), notifications.ListOptions{Saved: true})),
+		"SetRepoWatch": s.(notifications.RepoWatchService).SetRepoWatch(context.Background(), repo, nil, notifications.RepoWatch{Level: notifications.WatchCustom}),
+	} {
+		if !errors.Is(err, notifications.ErrNotSupported) {
+			t.Errorf("%s: got error %v, want ErrNotSupported", name, err)
+		}
+	}
+}
+
+// listErr returns the error of a List call.
+func listErr(_ notifications.Notifications, _ string, err error) error { return err }
@@ -0,0 +1,167 @@
+package githubapi
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	githubv3 "github.com/google/go-github/github"
+	"github.com/shurcooL/notifications"
+)
+
+var _ notifications.Watcher = &service{}
+
+// Watch watches for changes to notifications by polling GitHub.
+// It polls no more often than GitHub allows via the X-Poll-Interval header,
+// and makes a conditional request first, so that unread notifications are
+// only listed again when GitHub reports they were modified.
+//
+// GitHub doesn't tell apart notifications that were marked read
+// from ones that went away for other reasons, so unread notifications
+// that are no longer listed are reported as read.
+func (s *service) Watch(ctx context.Context) (<-chan notifications.Change, error) {
+	// Get initial unread notifications to compare against,
+	// and when they were last modified.
+	_, lastModified, err := s.notificationsModified(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	ns, _, err := s.List(ctx, notifications.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan notifications.Change)
+	go s.poll(ctx, ns, lastModified, ch)
+	return ch, nil
+}
+
+// poll polls for unread notifications, and sends changes since prev to ch.
+// lastModified is the Last-Modified time of prev, as reported by GitHub.
+// It closes ch when ctx is done.
+func (s *service) poll(ctx context.Context, prev notifications.Notifications, lastModified string, ch chan<- notifications.Change) {
+	defer close(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.getPollInterval()):
+		}
+
+		modified, lm, err := s.notificationsModified(ctx, lastModified)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			log.Println("Watch: failed to check for modified notifications:", err)
+			continue
+		} else if !modified {
+			continue
+		}
+		ns, _, err := s.List(ctx, notifications.ListOptions{})
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			log.Println("Watch: failed to List:", err)
+			continue
+		}
+		lastModified = lm
+		for _, c := range changes(prev, ns) {
+			select {
+			case ch <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+		prev = ns
+	}
+}
+
+// notificationsModified reports whether unread notifications were modified
+// since lastModified, a Last-Modified header value from a previous call.
+// An empty lastModified is always considered modified.
+// It returns the current Last-Modified header value.
+//
+// The request is conditional, so GitHub answers it with a cheap
+// 304 Not Modified when there are no changes.
+func (s *service) notificationsModified(ctx context.Context, lastModified string) (modified bool, _ string, _ error) {
+	req, err := s.clV3.NewRequest("GET", "notifications?per_page=1", nil)
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := s.clV3.Do(ctx, req, nil)
+	if resp != nil {
+		s.setPollInterval(resp)
+		if resp.StatusCode == http.StatusNotModified {
+			return false, lastModified, nil
+		}
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return true, resp.Header.Get("Last-Modified"), nil
+}
+
+// changes returns changes between unread notifications prev and cur.
+func changes(prev, cur notifications.Notifications) []notifications.Change {
+	type threadKey struct {
+		RepoSpec   notifications.RepoSpec
+		ThreadType string
+		ThreadID   uint64
+	}
+	var (
+		prevByKey = make(map[threadKey]notifications.Notification)
+		curKeys   = make(map[threadKey]bool)
+		cs        []notifications.Change
+	)
+	for _, n := range prev {
+		prevByKey[threadKey{n.RepoSpec, n.ThreadType, n.ThreadID}] = n
+	}
+	for _, n := range cur {
+		n := n
+		key := threadKey{n.RepoSpec, n.ThreadType, n.ThreadID}
+		curKeys[key] = true
+		p, ok := prevByKey[key]
+		switch {
+		case !ok:
+			cs = append(cs, notifications.Change{Op: notifications.ChangeCreated, RepoSpec: n.RepoSpec, ThreadType: n.ThreadType, ThreadID: n.ThreadID, Notification: &n})
+		case !n.UpdatedAt.Equal(p.UpdatedAt):
+			cs = append(cs, notifications.Change{Op: notifications.ChangeUpdated, RepoSpec: n.RepoSpec, ThreadType: n.ThreadType, ThreadID: n.ThreadID, Notification: &n})
+		}
+	}
+	for _, n := range prev {
+		if curKeys[threadKey{n.RepoSpec, n.ThreadType, n.ThreadID}] {
+			continue
+		}
+		cs = append(cs, notifications.Change{Op: notifications.ChangeRead, RepoSpec: n.RepoSpec, ThreadType: n.ThreadType, ThreadID: n.ThreadID})
+	}
+	return cs
+}
+
+// defaultPollInterval is used until GitHub provides an X-Poll-Interval.
+const defaultPollInterval = 60 * time.Second
+
+// setPollInterval records the X-Poll-Interval header value of resp, if any.
+func (s *service) setPollInterval(resp *githubv3.Response) {
+	seconds, err := strconv.Atoi(resp.Header.Get("X-Poll-Interval"))
+	if err != nil || seconds <= 0 {
+		return
+	}
+	s.pollIntervalMu.Lock()
+	s.pollInterval = time.Duration(seconds) * time.Second
+	s.pollIntervalMu.Unlock()
+}
+
+// getPollInterval returns how long to wait before polling again.
+func (s *service) getPollInterval() time.Duration {
+	s.pollIntervalMu.Lock()
+	defer s.pollIntervalMu.Unlock()
+	if s.pollInterval == 0 {
+		return defaultPollInterval
+	}
+	return s.pollInterval
+}
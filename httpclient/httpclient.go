@@ -0,0 +1,218 @@
+// Package httpclient contains notifications.Service implementation over HTTP.
+//
+// It talks to the HTTP API served by package httphandler.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/users"
+)
+
+// NewNotifications creates a client that implements notifications.Service remotely over HTTP.
+// If a nil httpClient is provided, http.DefaultClient will be used.
+// scheme and host can be empty strings to target local service.
+//
+// Permission errors are returned as os.ErrPermission, not found errors as os.ErrNotExist,
+// unsupported operations as notifications.ErrNotSupported, and malformed cursors as errors
+// wrapping notifications.ErrInvalidCursor, so that they can be told apart as they are
+// with other implementations.
+func NewNotifications(httpClient *http.Client, scheme, host string) notifications.Service {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Notifications{
+		client:  httpClient,
+		baseURL: &url.URL{Scheme: scheme, Host: host},
+	}
+}
+
+// Notifications implements notifications.Service remotely over HTTP.
+// Use NewNotifications for creation, zero value of Notifications is unfit for use.
+type Notifications struct {
+	client  *http.Client // HTTP client for API requests. If nil, http.DefaultClient should be used.
+	baseURL *url.URL     // Base URL for API requests.
+}
+
+var _ notifications.Service = &Notifications{}
+
+func (n *Notifications) List(ctx context.Context, opt notifications.ListOptions) (notifications.Notifications, string, error) {
+	var resp httproute.ListResponse
+	err := n.get(ctx, httproute.List, httproute.ListQuery(opt), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	var ns notifications.Notifications
+	for _, wn := range resp.Notifications {
+		ns = append(ns, wn.Notification())
+	}
+	return ns, resp.NextCursor, nil
+}
+
+func (n *Notifications) Count(ctx context.Context, opt notifications.CountOptions) (uint64, error) {
+	var resp httproute.CountResponse
+	err := n.get(ctx, httproute.Count, httproute.CountQuery(opt), &resp)
+	return resp.Count, err
+}
+
+func (n *Notifications) MarkAllRead(ctx context.Context, opt notifications.MarkAllReadOptions) error {
+	req := httproute.MarkAllReadRequest{LastReadAt: opt.LastReadAt}
+	if opt.Repo != nil {
+		req.Repo = opt.Repo.URI
+	}
+	return n.post(ctx, httproute.MarkAllRead, req)
+}
+
+func (n *Notifications) MarkUnread(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	return n.post(ctx, httproute.MarkUnread, thread(repo, threadType, threadID))
+}
+
+func (n *Notifications) Delete(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	return n.post(ctx, httproute.Delete, thread(repo, threadType, threadID))
+}
+
+func (n *Notifications) Snooze(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, until time.Time) error {
+	return n.post(ctx, httproute.Snooze, httproute.SnoozeRequest{
+		Thread: thread(repo, threadType, threadID),
+		Until:  until,
+	})
+}
+
+func (n *Notifications) SetSaved(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, saved bool) error {
+	return n.post(ctx, httproute.SetSaved, httproute.SetSavedRequest{
+		Thread: thread(repo, threadType, threadID),
+		Saved:  saved,
+	})
+}
+
+func (n *Notifications) Subscribe(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	return n.post(ctx, httproute.Subscribe, httproute.SubscribeRequest{
+		Thread:      thread(repo, threadType, threadID),
+		Subscribers: httproute.FromUserSpecs(subscribers),
+	})
+}
+
+func (n *Notifications) Unsubscribe(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	return n.post(ctx, httproute.Unsubscribe, httproute.SubscribeRequest{
+		Thread:      thread(repo, threadType, threadID),
+		Subscribers: httproute.FromUserSpecs(subscribers),
+	})
+}
+
+func (n *Notifications) Ignore(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error {
+	return n.post(ctx, httproute.Ignore, httproute.SubscribeRequest{
+		Thread:      thread(repo, threadType, threadID),
+		Subscribers: httproute.FromUserSpecs(subscribers),
+	})
+}
+
+func (n *Notifications) MarkRead(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64) error {
+	return n.post(ctx, httproute.MarkRead, thread(repo, threadType, threadID))
+}
+
+func (n *Notifications) MarkReadBatch(ctx context.Context, threads []notifications.ThreadRef) error {
+	var req httproute.MarkReadBatchRequest
+	for _, t := range threads {
+		req.Threads = append(req.Threads, thread(t.RepoSpec, t.ThreadType, t.ThreadID))
+	}
+	return n.post(ctx, httproute.MarkReadBatch, req)
+}
+
+func (n *Notifications) Notify(ctx context.Context, repo notifications.RepoSpec, threadType string, threadID uint64, nr notifications.NotificationRequest) error {
+	return n.post(ctx, httproute.Notify, httproute.NotifyRequest{
+		Thread:              thread(repo, threadType, threadID),
+		NotificationRequest: httproute.FromNotificationRequest(nr),
+	})
+}
+
+func thread(repo notifications.RepoSpec, threadType string, threadID uint64) httproute.Thread {
+	return httproute.Thread{Repo: repo.URI, ThreadType: threadType, ThreadID: threadID}
+}
+
+// get makes a GET request to path with query,
+// and decodes the JSON response into v.
+func (n *Notifications) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := url.URL{Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequest(http.MethodGet, n.baseURL.ResolveReference(&u).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post makes a POST request to path with body encoded as JSON.
+func (n *Notifications) post(ctx context.Context, path string, body interface{}) error {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(body)
+	if err != nil {
+		return err
+	}
+	u := url.URL{Path: path}
+	req, err := http.NewRequest(http.MethodPost, n.baseURL.ResolveReference(&u).String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	default:
+		return responseError(resp)
+	}
+}
+
+// responseError turns a non-successful response into an error.
+// Permission, not found, unsupported operation and invalid cursor errors
+// are mapped back to their sentinel errors.
+func responseError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return os.ErrPermission
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusNotImplemented:
+		return notifications.ErrNotSupported
+	}
+	var e httproute.Error
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(body, &e) != nil || e.Error == "" {
+		e.Error = string(bytes.TrimSpace(body))
+	}
+	if resp.StatusCode == http.StatusBadRequest && e.Code == httproute.ErrorCodeInvalidCursor {
+		return codeError{msg: e.Error, err: notifications.ErrInvalidCursor}
+	}
+	return fmt.Errorf("did not get acceptable status code: %v body: %q", resp.Status, e.Error)
+}
+
+// codeError is an error reported by the server, which wraps
+// the sentinel error identified by its httproute.Error.Code.
+type codeError struct {
+	msg string
+	err error
+}
+
+func (e codeError) Error() string { return e.msg }
+func (e codeError) Unwrap() error { return e.err }
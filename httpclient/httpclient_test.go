@@ -0,0 +1,148 @@
+package httpclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httpclient"
+	"github.com/shurcooL/notifications/httphandler"
+	"github.com/shurcooL/notifications/internal/apitest"
+	"github.com/shurcooL/users"
+)
+
+func TestRoundTrip(t *testing.T) {
+	h := apitest.NewHandler(t)
+	local := h.Notifications
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := func(user string) notifications.Service {
+		return httpclient.NewNotifications(&http.Client{Transport: userTransport(user)}, u.Scheme, u.Host)
+	}
+
+	// Unauthenticated requests get permission errors.
+	if _, err := remote("").Count(context.Background(), notifications.CountOptions{}); !os.IsPermission(err) {
+		t.Errorf("unauthenticated Count: got error %v, want permission error", err)
+	}
+
+	repo := notifications.RepoSpec{URI: "repo"}
+	err = remote("1").Subscribe(context.Background(), repo, "issues", 1, []users.UserSpec{{ID: 1, Domain: "example.org"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote("2").Notify(context.Background(), repo, "issues", 1, notifications.NotificationRequest{
+		Title:     "Issue 1",
+		Kind:      notifications.KindIssueOpen,
+		State:     notifications.SubjectStateOpen,
+		Color:     notifications.RGB{R: 0x6c, G: 0xc6, B: 0x44},
+		Actor:     users.UserSpec{ID: 2, Domain: "example.org"},
+		UpdatedAt: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		HTMLURL:   "/issues/1",
+		Body:      "Hello.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote("1").SetSaved(context.Background(), repo, "issues", 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// What List returns over HTTP is what the underlying service returns.
+	want, _, err := local.List(apitest.WithUser(context.Background(), 1), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := remote("1").List(context.Background(), notifications.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 1 || want[0].Actor.Email == "" {
+		t.Fatalf("unexpected notifications from underlying service: %+v", want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	count, err := remote("1").Count(context.Background(), notifications.CountOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got count %d, want 1", count)
+	}
+	err = remote("1").MarkReadBatch(context.Background(), []notifications.ThreadRef{{RepoSpec: repo, ThreadType: "issues", ThreadID: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err = remote("1").Count(context.Background(), notifications.CountOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("got count %d, want 0", count)
+	}
+}
+
+// userTransport is an http.RoundTripper that authenticates
+// requests as the user with ID in the X-User header.
+type userTransport string
+
+func (t userTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-User", string(t))
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestInvalidCursor(t *testing.T) {
+	ts := httptest.NewServer(apitest.NewHandler(t))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := httpclient.NewNotifications(&http.Client{Transport: userTransport("1")}, u.Scheme, u.Host)
+
+	_, _, err = c.List(context.Background(), notifications.ListOptions{PageSize: 1, Cursor: "x"})
+	if !errors.Is(err, notifications.ErrInvalidCursor) {
+		t.Errorf("got error %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	ts := httptest.NewServer(httphandler.Handler{Notifications: unsupportedService{}})
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := httpclient.NewNotifications(nil, u.Scheme, u.Host)
+
+	err = c.Snooze(context.Background(), notifications.RepoSpec{URI: "repo"}, "issues", 1, time.Now())
+	if !errors.Is(err, notifications.ErrNotSupported) {
+		t.Errorf("got error %v, want ErrNotSupported", err)
+	}
+}
+
+// unsupportedService is a notifications.Service that doesn't support snoozing.
+type unsupportedService struct {
+	notifications.Service
+}
+
+func (unsupportedService) Snooze(context.Context, notifications.RepoSpec, string, uint64, time.Time) error {
+	return fmt.Errorf("Snooze: %w", notifications.ErrNotSupported)
+}
@@ -0,0 +1,306 @@
+// Package httphandler contains an HTTP API handler for notifications.Service.
+//
+// Requests and responses are JSON, using the wire types of package httproute.
+// The exception is httproute.Stream, which responds with Server-Sent Events.
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/users"
+)
+
+// Handler is an HTTP API handler for notifications.Service.
+// It serves the routes in package httproute.
+type Handler struct {
+	Notifications notifications.Service
+
+	// Context, if not nil, returns the context for serving req,
+	// such as one that carries the authenticated user.
+	// An error from Context results in a 401 Unauthorized response.
+	// If nil, req.Context() is used.
+	Context func(req *http.Request) (context.Context, error)
+
+	// Users, if not nil, is used to tell apart permission errors
+	// due to no authenticated user (401 Unauthorized) from the rest (403 Forbidden).
+	// If nil, all permission errors result in 403 Forbidden.
+	Users users.Service
+
+	// PollInterval is how often Stream polls Count. When Notifications
+	// implements notifications.Watcher, Count is also polled on changes,
+	// and polling only catches changes that Watch doesn't send.
+	// If zero, 10 seconds is used.
+	PollInterval time.Duration
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == httproute.Stream {
+		h.serveStream(w, req)
+		return
+	}
+
+	var serve func(context.Context, *http.Request) (interface{}, error)
+	var method string
+	switch req.URL.Path {
+	case httproute.List:
+		serve, method = h.list, http.MethodGet
+	case httproute.Count:
+		serve, method = h.count, http.MethodGet
+	case httproute.MarkAllRead:
+		serve, method = h.markAllRead, http.MethodPost
+	case httproute.MarkUnread:
+		serve, method = h.markUnread, http.MethodPost
+	case httproute.Delete:
+		serve, method = h.delete, http.MethodPost
+	case httproute.Snooze:
+		serve, method = h.snooze, http.MethodPost
+	case httproute.SetSaved:
+		serve, method = h.setSaved, http.MethodPost
+	case httproute.Subscribe:
+		serve, method = h.subscribe, http.MethodPost
+	case httproute.Unsubscribe:
+		serve, method = h.unsubscribe, http.MethodPost
+	case httproute.Ignore:
+		serve, method = h.ignore, http.MethodPost
+	case httproute.MarkRead:
+		serve, method = h.markRead, http.MethodPost
+	case httproute.MarkReadBatch:
+		serve, method = h.markReadBatch, http.MethodPost
+	case httproute.Notify:
+		serve, method = h.notify, http.MethodPost
+	default:
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+	if req.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method should be %s", method))
+		return
+	}
+
+	ctx, err := h.context(req)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	v, err := serve(ctx, req)
+	if err != nil {
+		h.handleError(ctx, w, req, err)
+		return
+	}
+	if v == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (h Handler) list(ctx context.Context, req *http.Request) (interface{}, error) {
+	opt, err := httproute.ParseListQuery(req.URL.Query())
+	if err != nil {
+		return nil, badRequest{err}
+	}
+	ns, nextCursor, err := h.Notifications.List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	resp := httproute.ListResponse{
+		Notifications: []httproute.Notification{},
+		NextCursor:    nextCursor,
+	}
+	for _, n := range ns {
+		resp.Notifications = append(resp.Notifications, httproute.FromNotification(n))
+	}
+	return resp, nil
+}
+
+func (h Handler) count(ctx context.Context, req *http.Request) (interface{}, error) {
+	n, err := h.Notifications.Count(ctx, httproute.ParseCountQuery(req.URL.Query()))
+	if err != nil {
+		return nil, err
+	}
+	return httproute.CountResponse{Count: n}, nil
+}
+
+func (h Handler) markRead(ctx context.Context, req *http.Request) (interface{}, error) {
+	var t httproute.Thread
+	err := decodeJSON(req, &t)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.MarkRead(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID)
+}
+
+func (h Handler) markReadBatch(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.MarkReadBatchRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	var threads []notifications.ThreadRef
+	for _, t := range r.Threads {
+		threads = append(threads, notifications.ThreadRef{RepoSpec: notifications.RepoSpec{URI: t.Repo}, ThreadType: t.ThreadType, ThreadID: t.ThreadID})
+	}
+	return nil, h.Notifications.MarkReadBatch(ctx, threads)
+}
+
+func (h Handler) markUnread(ctx context.Context, req *http.Request) (interface{}, error) {
+	var t httproute.Thread
+	err := decodeJSON(req, &t)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.MarkUnread(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID)
+}
+
+func (h Handler) delete(ctx context.Context, req *http.Request) (interface{}, error) {
+	var t httproute.Thread
+	err := decodeJSON(req, &t)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Delete(ctx, notifications.RepoSpec{URI: t.Repo}, t.ThreadType, t.ThreadID)
+}
+
+func (h Handler) snooze(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.SnoozeRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Snooze(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, r.Until)
+}
+
+func (h Handler) setSaved(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.SetSavedRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.SetSaved(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, r.Saved)
+}
+
+func (h Handler) markAllRead(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.MarkAllReadRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	opt := notifications.MarkAllReadOptions{LastReadAt: r.LastReadAt}
+	if r.Repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: r.Repo}
+	}
+	return nil, h.Notifications.MarkAllRead(ctx, opt)
+}
+
+func (h Handler) subscribe(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.SubscribeRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Subscribe(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, httproute.UserSpecs(r.Subscribers))
+}
+
+func (h Handler) unsubscribe(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.SubscribeRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Unsubscribe(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, httproute.UserSpecs(r.Subscribers))
+}
+
+func (h Handler) ignore(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.SubscribeRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Ignore(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, httproute.UserSpecs(r.Subscribers))
+}
+
+func (h Handler) notify(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r httproute.NotifyRequest
+	err := decodeJSON(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.Notifications.Notify(ctx, notifications.RepoSpec{URI: r.Repo}, r.ThreadType, r.ThreadID, r.NotificationRequest.NotificationRequest())
+}
+
+// context returns the context for serving req.
+func (h Handler) context(req *http.Request) (context.Context, error) {
+	if h.Context == nil {
+		return req.Context(), nil
+	}
+	return h.Context(req)
+}
+
+// handleError writes a response for err, mapping it to an HTTP status code.
+func (h Handler) handleError(ctx context.Context, w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, notifications.ErrInvalidCursor):
+		writeJSON(w, http.StatusBadRequest, httproute.Error{Error: err.Error(), Code: httproute.ErrorCodeInvalidCursor})
+	case isBadRequest(err):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case os.IsPermission(err):
+		if h.Users != nil {
+			if me, err := h.Users.GetAuthenticatedSpec(ctx); err == nil && me.ID == 0 {
+				writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+				return
+			}
+		}
+		writeError(w, http.StatusForbidden, "403 Forbidden")
+	case os.IsNotExist(err):
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	case errors.Is(err, notifications.ErrNotSupported):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	default:
+		log.Printf("httphandler: %s %s: %v\n", req.Method, req.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, "500 Internal Server Error")
+	}
+}
+
+// badRequest is an error caused by a malformed request.
+type badRequest struct{ err error }
+
+func (b badRequest) Error() string { return b.err.Error() }
+
+// isBadRequest reports whether err is caused by a malformed request.
+func isBadRequest(err error) bool {
+	_, ok := err.(badRequest)
+	return ok
+}
+
+// decodeJSON decodes the JSON body of req into v.
+func decodeJSON(req *http.Request, v interface{}) error {
+	err := json.NewDecoder(req.Body).Decode(v)
+	if err != nil {
+		return badRequest{fmt.Errorf("invalid request body: %v", err)}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("httphandler: error encoding JSON response:", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, error string) {
+	writeJSON(w, code, httproute.Error{Error: error})
+}
@@ -0,0 +1,132 @@
+package httphandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/notifications/internal/apitest"
+)
+
+func TestHandler(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	// Unauthenticated requests are rejected.
+	if got, want := do(t, ts, "GET", httproute.Count, "", nil, nil), http.StatusUnauthorized; got != want {
+		t.Errorf("unauthenticated: got status %d, want %d", got, want)
+	}
+	// Invalid credentials are rejected.
+	if got, want := do(t, ts, "GET", httproute.Count, "bad", nil, nil), http.StatusUnauthorized; got != want {
+		t.Errorf("invalid credentials: got status %d, want %d", got, want)
+	}
+	// Wrong methods are rejected.
+	if got, want := do(t, ts, "GET", httproute.MarkRead, "1", nil, nil), http.StatusMethodNotAllowed; got != want {
+		t.Errorf("wrong method: got status %d, want %d", got, want)
+	}
+	// Malformed requests are rejected.
+	if got, want := do(t, ts, "GET", httproute.List+"?page_size=x", "1", nil, nil), http.StatusBadRequest; got != want {
+		t.Errorf("malformed request: got status %d, want %d", got, want)
+	}
+	if got, want := do(t, ts, "GET", httproute.List+"?page_size=1&cursor=x", "1", nil, nil), http.StatusBadRequest; got != want {
+		t.Errorf("malformed cursor: got status %d, want %d", got, want)
+	}
+
+	// User 1 subscribes to issue 1, and user 2 comments on it.
+	thread := httproute.Thread{Repo: "repo", ThreadType: "issues", ThreadID: 1}
+	status := do(t, ts, "POST", httproute.Subscribe, "1", httproute.SubscribeRequest{
+		Thread:      thread,
+		Subscribers: []httproute.UserSpec{{ID: 1, Domain: "example.org"}},
+	}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("Subscribe: got status %d", status)
+	}
+	status = do(t, ts, "POST", httproute.Notify, "2", httproute.NotifyRequest{
+		Thread: thread,
+		NotificationRequest: httproute.NotificationRequest{
+			Title:     "Issue 1",
+			Color:     "#6cc644",
+			Actor:     httproute.UserSpec{ID: 2, Domain: "example.org"},
+			UpdatedAt: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+			HTMLURL:   "/issues/1",
+		},
+	}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("Notify: got status %d", status)
+	}
+
+	var count httproute.CountResponse
+	if status := do(t, ts, "GET", httproute.Count, "1", nil, &count); status != http.StatusOK {
+		t.Fatalf("Count: got status %d", status)
+	}
+	if count.Count != 1 {
+		t.Errorf("got count %d, want 1", count.Count)
+	}
+	var list httproute.ListResponse
+	if status := do(t, ts, "GET", httproute.List, "1", nil, &list); status != http.StatusOK {
+		t.Fatalf("List: got status %d", status)
+	}
+	if len(list.Notifications) != 1 {
+		t.Fatalf("want 1 notification, got: %+v", list.Notifications)
+	}
+	n := list.Notifications[0].Notification()
+	if n.Title != "Issue 1" || n.Actor.Login != "gopher2" || n.Color.HexString() != "#6cc644" {
+		t.Errorf("got unexpected notification: %+v", n)
+	}
+
+	status = do(t, ts, "POST", httproute.MarkRead, "1", thread, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("MarkRead: got status %d", status)
+	}
+	if status := do(t, ts, "GET", httproute.Count, "1", nil, &count); status != http.StatusOK {
+		t.Fatalf("Count: got status %d", status)
+	}
+	if count.Count != 0 {
+		t.Errorf("got count %d, want 0", count.Count)
+	}
+	status = do(t, ts, "POST", httproute.MarkAllRead, "1", httproute.MarkAllReadRequest{}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("MarkAllRead: got status %d", status)
+	}
+}
+
+// newServer returns a test server serving apitest.NewHandler.
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(apitest.NewHandler(t))
+}
+
+// do makes a request to ts as user, with body encoded as JSON if not nil,
+// and decodes the JSON response into resp if not nil. It returns the status code.
+func do(t *testing.T, ts *httptest.Server, method, path, user string, body, resp interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&buf).Encode(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if resp != nil && r.StatusCode == http.StatusOK {
+		err := json.NewDecoder(r.Body).Decode(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r.StatusCode
+}
@@ -0,0 +1,160 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+)
+
+// serveStream serves httproute.Stream. It streams the unread count
+// of authenticated user as Server-Sent Events, sending a count event
+// at the start and whenever the count changes. The query is that of Count.
+//
+// If the query has notifications=1, a notification event is also sent
+// for every created or updated notification. That's only supported
+// when Notifications implements notifications.Watcher.
+//
+// The count is polled every PollInterval. When Notifications implements
+// notifications.Watcher, it's also recomputed on changes.
+func (h Handler) serveStream(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method should be %s", http.MethodGet))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	ctx, err := h.context(req)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// Stop when the client goes away, even if ctx isn't derived from req.Context().
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-req.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	opt := httproute.ParseCountQuery(req.URL.Query())
+	sendNotifications := req.URL.Query().Get("notifications") == "1"
+
+	// Start watching before the initial count, so no change is missed in between.
+	var changes <-chan notifications.Change
+	if watcher, ok := h.Notifications.(notifications.Watcher); ok {
+		changes, err = watcher.Watch(ctx)
+		if err != nil {
+			h.handleError(ctx, w, req, err)
+			return
+		}
+	}
+	count, err := h.Notifications.Count(ctx, opt)
+	if err != nil {
+		h.handleError(ctx, w, req, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvent(w, httproute.CountEvent, httproute.CountResponse{Count: count}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	// updateCount sends a count event if the count changed.
+	updateCount := func() error {
+		c, err := h.Notifications.Count(ctx, opt)
+		if err != nil {
+			return err
+		}
+		if c == count {
+			return nil
+		}
+		count = c
+		return writeEvent(w, httproute.CountEvent, httproute.CountResponse{Count: count})
+	}
+
+	// The count is polled every PollInterval, and also recomputed on changes
+	// when watching. Polling catches count changes that aren't sent as changes,
+	// such as snoozes ending.
+	pollInterval := h.PollInterval
+	if pollInterval == 0 {
+		pollInterval = 10 * time.Second
+	}
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case c, ok := <-changes:
+			if !ok {
+				return
+			}
+			if sendNotifications && c.Notification != nil && matchesCount(*c.Notification, opt) {
+				err := writeEvent(w, httproute.NotificationEvent, httproute.FromNotification(*c.Notification))
+				if err != nil {
+					return
+				}
+			}
+			// Coalesce changes that are already pending into a single count.
+			if len(changes) > 0 {
+				flusher.Flush()
+				continue
+			}
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := updateCount(); err != nil {
+			streamError(ctx, req, err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// matchesCount reports whether n matches the filters of opt.
+func matchesCount(n notifications.Notification, opt notifications.CountOptions) bool {
+	switch {
+	case opt.Repo != nil && n.RepoSpec != *opt.Repo:
+		return false
+	case opt.ThreadType != "" && n.ThreadType != opt.ThreadType:
+		return false
+	case opt.Participating && !n.Participating:
+		return false
+	case opt.Mentioned && !n.Mentioned:
+		return false
+	default:
+		return true
+	}
+}
+
+// writeEvent writes a Server-Sent Event with data encoded as JSON.
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// streamError logs err, unless it's due to the client going away.
+func streamError(ctx context.Context, req *http.Request, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	log.Printf("httphandler: %s %s: %v\n", req.Method, req.URL.Path, err)
+}
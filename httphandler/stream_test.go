@@ -0,0 +1,196 @@
+package httphandler_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/notifications/internal/apitest"
+)
+
+func TestStream(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	// Unauthenticated requests are rejected.
+	if got, want := do(t, ts, "GET", httproute.Stream, "", nil, nil), http.StatusUnauthorized; got != want {
+		t.Errorf("unauthenticated: got status %d, want %d", got, want)
+	}
+
+	events, cancel := openStream(t, ts, "?notifications=1")
+	defer cancel()
+	if got, want := readCount(t, events), uint64(0); got != want {
+		t.Errorf("initial count: got %d, want %d", got, want)
+	}
+
+	notify(t, ts)
+	event, data := readEvent(t, events)
+	if event != httproute.NotificationEvent {
+		t.Fatalf("got event %q, want %q", event, httproute.NotificationEvent)
+	}
+	var n httproute.Notification
+	err := json.Unmarshal([]byte(data), &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Title != "Issue 1" || n.Actor.Login != "gopher2" {
+		t.Errorf("got unexpected notification: %+v", n)
+	}
+	if got, want := readCount(t, events), uint64(1); got != want {
+		t.Errorf("count after Notify: got %d, want %d", got, want)
+	}
+
+	status := do(t, ts, "POST", httproute.MarkRead, "1", httproute.Thread{Repo: "repo", ThreadType: "issues", ThreadID: 1}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("MarkRead: got status %d", status)
+	}
+	if got, want := readCount(t, events), uint64(0); got != want {
+		t.Errorf("count after MarkRead: got %d, want %d", got, want)
+	}
+}
+
+func TestStreamPolling(t *testing.T) {
+	h := apitest.NewHandler(t)
+	h.Notifications = struct{ notifications.Service }{h.Notifications} // Hide notifications.Watcher.
+	h.PollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	events, cancel := openStream(t, ts, "")
+	defer cancel()
+	if got, want := readCount(t, events), uint64(0); got != want {
+		t.Errorf("initial count: got %d, want %d", got, want)
+	}
+	notify(t, ts)
+	if got, want := readCount(t, events), uint64(1); got != want {
+		t.Errorf("count after Notify: got %d, want %d", got, want)
+	}
+}
+
+func TestStreamWatcherPolling(t *testing.T) {
+	h := apitest.NewHandler(t)
+	h.Notifications = silentWatcher{h.Notifications}
+	h.PollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	// Count changes that Watch doesn't send are still noticed by polling.
+	events, cancel := openStream(t, ts, "")
+	defer cancel()
+	if got, want := readCount(t, events), uint64(0); got != want {
+		t.Errorf("initial count: got %d, want %d", got, want)
+	}
+	notify(t, ts)
+	if got, want := readCount(t, events), uint64(1); got != want {
+		t.Errorf("count after Notify: got %d, want %d", got, want)
+	}
+}
+
+// silentWatcher is a notifications.Watcher that never sends changes.
+type silentWatcher struct {
+	notifications.Service
+}
+
+func (silentWatcher) Watch(ctx context.Context) (<-chan notifications.Change, error) {
+	changes := make(chan notifications.Change)
+	go func() {
+		<-ctx.Done()
+		close(changes)
+	}()
+	return changes, nil
+}
+
+// openStream opens the stream of user 1 with query.
+// The stream is closed by calling the returned func.
+func openStream(t *testing.T, ts *httptest.Server, query string) (*bufio.Reader, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequest("GET", ts.URL+httproute.Stream+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-User", "1")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("got Content-Type %q, want %q", got, want)
+	}
+	return bufio.NewReader(resp.Body), func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+// readEvent reads the next Server-Sent Event from r.
+func readEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// readCount reads the next Server-Sent Event from r,
+// which must be a count event, and returns the count.
+func readCount(t *testing.T, r *bufio.Reader) uint64 {
+	t.Helper()
+	event, data := readEvent(t, r)
+	if event != httproute.CountEvent {
+		t.Fatalf("got event %q, want %q", event, httproute.CountEvent)
+	}
+	var c httproute.CountResponse
+	err := json.Unmarshal([]byte(data), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Count
+}
+
+// notify subscribes user 1 to issue 1, and has user 2 comment on it.
+func notify(t *testing.T, ts *httptest.Server) {
+	t.Helper()
+	thread := httproute.Thread{Repo: "repo", ThreadType: "issues", ThreadID: 1}
+	status := do(t, ts, "POST", httproute.Subscribe, "1", httproute.SubscribeRequest{
+		Thread:      thread,
+		Subscribers: []httproute.UserSpec{{ID: 1, Domain: "example.org"}},
+	}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("Subscribe: got status %d", status)
+	}
+	status = do(t, ts, "POST", httproute.Notify, "2", httproute.NotifyRequest{
+		Thread: thread,
+		NotificationRequest: httproute.NotificationRequest{
+			Title:     "Issue 1",
+			Color:     "#6cc644",
+			Actor:     httproute.UserSpec{ID: 2, Domain: "example.org"},
+			UpdatedAt: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+			HTMLURL:   "/issues/1",
+		},
+	}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("Notify: got status %d", status)
+	}
+}
@@ -0,0 +1,27 @@
+// Package httproute contains route paths and wire types
+// of the notifications HTTP API, as served by httphandler.
+package httproute
+
+// Route paths of the notifications HTTP API.
+const (
+	List          = "/api/notifications/list"            // GET.
+	Count         = "/api/notifications/count"           // GET.
+	MarkAllRead   = "/api/notifications/mark-all-read"   // POST.
+	MarkUnread    = "/api/notifications/mark-unread"     // POST.
+	Delete        = "/api/notifications/delete"          // POST.
+	Snooze        = "/api/notifications/snooze"          // POST.
+	SetSaved      = "/api/notifications/set-saved"       // POST.
+	Subscribe     = "/api/notifications/subscribe"       // POST.
+	Unsubscribe   = "/api/notifications/unsubscribe"     // POST.
+	Ignore        = "/api/notifications/ignore"          // POST.
+	MarkRead      = "/api/notifications/mark-read"       // POST.
+	MarkReadBatch = "/api/notifications/mark-read-batch" // POST.
+	Notify        = "/api/notifications/notify"          // POST.
+	Stream        = "/api/notifications/stream"          // GET, Server-Sent Events.
+)
+
+// Event types sent by Stream. The data of each event is JSON.
+const (
+	CountEvent        = "count"        // Unread count changed. Data is a CountResponse.
+	NotificationEvent = "notification" // A notification was created or updated. Data is a Notification.
+)
@@ -0,0 +1,129 @@
+package httproute
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shurcooL/notifications"
+)
+
+// ListQuery encodes opt as the query of List.
+func ListQuery(opt notifications.ListOptions) url.Values {
+	q := make(url.Values)
+	if opt.Repo != nil {
+		q.Set("repo", opt.Repo.URI)
+	}
+	setBool(q, "all", opt.All)
+	if opt.PageSize != 0 {
+		q.Set("page_size", strconv.Itoa(opt.PageSize))
+	}
+	if opt.Cursor != "" {
+		q.Set("cursor", opt.Cursor)
+	}
+	setTime(q, "since", opt.Since)
+	setTime(q, "before", opt.Before)
+	if opt.ThreadType != "" {
+		q.Set("thread_type", opt.ThreadType)
+	}
+	if opt.Reason != "" {
+		q.Set("reason", string(opt.Reason))
+	}
+	setBool(q, "participating", opt.Participating)
+	setBool(q, "mentioned", opt.Mentioned)
+	setBool(q, "saved", opt.Saved)
+	if opt.Actor != nil {
+		q.Set("actor", marshalUserSpec(*opt.Actor))
+	}
+	return q
+}
+
+// ParseListQuery parses the query of List, as encoded by ListQuery.
+func ParseListQuery(q url.Values) (notifications.ListOptions, error) {
+	var opt notifications.ListOptions
+	if repo := q.Get("repo"); repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: repo}
+	}
+	opt.All = q.Get("all") == "1"
+	if s := q.Get("page_size"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return notifications.ListOptions{}, fmt.Errorf("invalid page_size: %v", err)
+		}
+		opt.PageSize = v
+	}
+	opt.Cursor = q.Get("cursor")
+	var err error
+	opt.Since, err = parseTime(q, "since")
+	if err != nil {
+		return notifications.ListOptions{}, err
+	}
+	opt.Before, err = parseTime(q, "before")
+	if err != nil {
+		return notifications.ListOptions{}, err
+	}
+	opt.ThreadType = q.Get("thread_type")
+	opt.Reason = notifications.Reason(q.Get("reason"))
+	opt.Participating = q.Get("participating") == "1"
+	opt.Mentioned = q.Get("mentioned") == "1"
+	opt.Saved = q.Get("saved") == "1"
+	if s := q.Get("actor"); s != "" {
+		actor, err := unmarshalUserSpec(s)
+		if err != nil {
+			return notifications.ListOptions{}, fmt.Errorf("invalid actor: %v", err)
+		}
+		opt.Actor = &actor
+	}
+	return opt, nil
+}
+
+// CountQuery encodes opt as the query of Count.
+func CountQuery(opt notifications.CountOptions) url.Values {
+	q := make(url.Values)
+	if opt.Repo != nil {
+		q.Set("repo", opt.Repo.URI)
+	}
+	if opt.ThreadType != "" {
+		q.Set("thread_type", opt.ThreadType)
+	}
+	setBool(q, "participating", opt.Participating)
+	setBool(q, "mentioned", opt.Mentioned)
+	return q
+}
+
+// ParseCountQuery parses the query of Count, as encoded by CountQuery.
+func ParseCountQuery(q url.Values) notifications.CountOptions {
+	var opt notifications.CountOptions
+	if repo := q.Get("repo"); repo != "" {
+		opt.Repo = &notifications.RepoSpec{URI: repo}
+	}
+	opt.ThreadType = q.Get("thread_type")
+	opt.Participating = q.Get("participating") == "1"
+	opt.Mentioned = q.Get("mentioned") == "1"
+	return opt
+}
+
+func setBool(q url.Values, key string, v bool) {
+	if v {
+		q.Set(key, "1")
+	}
+}
+
+func setTime(q url.Values, key string, t time.Time) {
+	if !t.IsZero() {
+		q.Set(key, t.Format(time.RFC3339Nano))
+	}
+}
+
+func parseTime(q url.Values, key string) (time.Time, error) {
+	s := q.Get(key)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,33 @@
+package httproute_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/notifications/httproute"
+	"github.com/shurcooL/users"
+)
+
+func TestListQuery(t *testing.T) {
+	opt := notifications.ListOptions{
+		Repo:          &notifications.RepoSpec{URI: "example.org/repo"},
+		All:           true,
+		PageSize:      10,
+		Cursor:        "unread:key",
+		Since:         time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		ThreadType:    "issues",
+		Reason:        notifications.ReasonMention,
+		Participating: true,
+		Saved:         true,
+		Actor:         &users.UserSpec{ID: 1, Domain: "example.org"},
+	}
+	got, err := httproute.ParseListQuery(httproute.ListQuery(opt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, opt) {
+		t.Errorf("got %+v, want %+v", got, opt)
+	}
+}
@@ -0,0 +1,315 @@
+package httproute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/notifications"
+	"github.com/shurcooL/users"
+)
+
+// Notification is the wire representation of notifications.Notification.
+type Notification struct {
+	Repo          string    `json:"repo"`
+	ThreadType    string    `json:"thread_type"`
+	ThreadID      uint64    `json:"thread_id"`
+	Title         string    `json:"title"`
+	Kind          string    `json:"kind,omitempty"`
+	State         string    `json:"state,omitempty"`
+	Icon          string    `json:"icon,omitempty"`
+	Color         string    `json:"color"` // Hexadecimal, like "#ff0000".
+	Actor         User      `json:"actor"`
+	Actors        []User    `json:"actors,omitempty"`
+	EventCount    int       `json:"event_count,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Read          bool      `json:"read"`
+	HTMLURL       string    `json:"html_url"`
+	Body          string    `json:"body,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Participating bool      `json:"participating"`
+	Mentioned     bool      `json:"mentioned"`
+	Saved         bool      `json:"saved"`
+}
+
+// FromNotification converts n to its wire representation.
+func FromNotification(n notifications.Notification) Notification {
+	var actors []User
+	for _, a := range n.Actors {
+		actors = append(actors, FromUser(a))
+	}
+	return Notification{
+		Repo:          n.RepoSpec.URI,
+		ThreadType:    n.ThreadType,
+		ThreadID:      n.ThreadID,
+		Title:         n.Title,
+		Kind:          string(n.Kind),
+		State:         string(n.State),
+		Icon:          string(n.Icon),
+		Color:         n.Color.HexString(),
+		Actor:         FromUser(n.Actor),
+		Actors:        actors,
+		EventCount:    n.EventCount,
+		UpdatedAt:     n.UpdatedAt,
+		Read:          n.Read,
+		HTMLURL:       n.HTMLURL,
+		Body:          n.Body,
+		Reason:        string(n.Reason),
+		Participating: n.Participating,
+		Mentioned:     n.Mentioned,
+		Saved:         n.Saved,
+	}
+}
+
+// Notification converts n to a notifications.Notification.
+func (n Notification) Notification() notifications.Notification {
+	var actors []users.User
+	for _, a := range n.Actors {
+		actors = append(actors, a.User())
+	}
+	return notifications.Notification{
+		RepoSpec:      notifications.RepoSpec{URI: n.Repo},
+		ThreadType:    n.ThreadType,
+		ThreadID:      n.ThreadID,
+		Title:         n.Title,
+		Kind:          notifications.Kind(n.Kind),
+		State:         notifications.SubjectState(n.State),
+		Icon:          notifications.OcticonID(n.Icon),
+		Color:         parseColor(n.Color),
+		Actor:         n.Actor.User(),
+		Actors:        actors,
+		EventCount:    n.EventCount,
+		UpdatedAt:     n.UpdatedAt,
+		Read:          n.Read,
+		HTMLURL:       n.HTMLURL,
+		Body:          n.Body,
+		Reason:        notifications.Reason(n.Reason),
+		Participating: n.Participating,
+		Mentioned:     n.Mentioned,
+		Saved:         n.Saved,
+	}
+}
+
+// NotificationRequest is the wire representation of notifications.NotificationRequest.
+type NotificationRequest struct {
+	Title     string     `json:"title"`
+	Kind      string     `json:"kind,omitempty"`
+	State     string     `json:"state,omitempty"`
+	Icon      string     `json:"icon,omitempty"`
+	Color     string     `json:"color"` // Hexadecimal, like "#ff0000".
+	Actor     UserSpec   `json:"actor"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	HTMLURL   string     `json:"html_url"`
+	Body      string     `json:"body,omitempty"`
+	Mentions  []UserSpec `json:"mentions,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// FromNotificationRequest converts nr to its wire representation.
+func FromNotificationRequest(nr notifications.NotificationRequest) NotificationRequest {
+	return NotificationRequest{
+		Title:     nr.Title,
+		Kind:      string(nr.Kind),
+		State:     string(nr.State),
+		Icon:      string(nr.Icon),
+		Color:     nr.Color.HexString(),
+		Actor:     FromUserSpec(nr.Actor),
+		UpdatedAt: nr.UpdatedAt,
+		HTMLURL:   nr.HTMLURL,
+		Body:      nr.Body,
+		Mentions:  FromUserSpecs(nr.Mentions),
+		Reason:    string(nr.Reason),
+	}
+}
+
+// NotificationRequest converts nr to a notifications.NotificationRequest.
+func (nr NotificationRequest) NotificationRequest() notifications.NotificationRequest {
+	return notifications.NotificationRequest{
+		Title:     nr.Title,
+		Kind:      notifications.Kind(nr.Kind),
+		State:     notifications.SubjectState(nr.State),
+		Icon:      notifications.OcticonID(nr.Icon),
+		Color:     parseColor(nr.Color),
+		Actor:     nr.Actor.UserSpec(),
+		UpdatedAt: nr.UpdatedAt,
+		HTMLURL:   nr.HTMLURL,
+		Body:      nr.Body,
+		Mentions:  UserSpecs(nr.Mentions),
+		Reason:    notifications.Reason(nr.Reason),
+	}
+}
+
+// User is the wire representation of users.User.
+type User struct {
+	UserSpec
+	CanonicalMe string     `json:"canonical_me,omitempty"`
+	Elsewhere   []UserSpec `json:"elsewhere,omitempty"`
+	Login       string     `json:"login"`
+	Name        string     `json:"name,omitempty"`
+	Email       string     `json:"email,omitempty"` // Public email.
+	AvatarURL   string     `json:"avatar_url,omitempty"`
+	HTMLURL     string     `json:"html_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SiteAdmin   bool       `json:"site_admin,omitempty"`
+}
+
+// FromUser converts u to its wire representation.
+func FromUser(u users.User) User {
+	return User{
+		UserSpec:    FromUserSpec(u.UserSpec),
+		CanonicalMe: u.CanonicalMe,
+		Elsewhere:   FromUserSpecs(u.Elsewhere),
+		Login:       u.Login,
+		Name:        u.Name,
+		Email:       u.Email,
+		AvatarURL:   u.AvatarURL,
+		HTMLURL:     u.HTMLURL,
+		CreatedAt:   u.CreatedAt,
+		SiteAdmin:   u.SiteAdmin,
+	}
+}
+
+// User converts u to a users.User.
+func (u User) User() users.User {
+	return users.User{
+		UserSpec:    u.UserSpec.UserSpec(),
+		CanonicalMe: u.CanonicalMe,
+		Elsewhere:   UserSpecs(u.Elsewhere),
+		Login:       u.Login,
+		Name:        u.Name,
+		Email:       u.Email,
+		AvatarURL:   u.AvatarURL,
+		HTMLURL:     u.HTMLURL,
+		CreatedAt:   u.CreatedAt,
+		SiteAdmin:   u.SiteAdmin,
+	}
+}
+
+// UserSpec is the wire representation of users.UserSpec.
+type UserSpec struct {
+	ID     uint64 `json:"id"`
+	Domain string `json:"domain"`
+}
+
+// FromUserSpec converts us to its wire representation.
+func FromUserSpec(us users.UserSpec) UserSpec {
+	return UserSpec{ID: us.ID, Domain: us.Domain}
+}
+
+// UserSpec converts us to a users.UserSpec.
+func (us UserSpec) UserSpec() users.UserSpec {
+	return users.UserSpec{ID: us.ID, Domain: us.Domain}
+}
+
+// FromUserSpecs converts uss to their wire representation.
+func FromUserSpecs(uss []users.UserSpec) []UserSpec {
+	var ws []UserSpec
+	for _, us := range uss {
+		ws = append(ws, FromUserSpec(us))
+	}
+	return ws
+}
+
+// UserSpecs converts uss to users.UserSpecs.
+func UserSpecs(uss []UserSpec) []users.UserSpec {
+	var us []users.UserSpec
+	for _, u := range uss {
+		us = append(us, u.UserSpec())
+	}
+	return us
+}
+
+// Thread identifies a thread. It's the request body of MarkRead, MarkUnread and Delete.
+type Thread struct {
+	Repo       string `json:"repo"`
+	ThreadType string `json:"thread_type"`
+	ThreadID   uint64 `json:"thread_id"`
+}
+
+// ListResponse is the response body of List.
+type ListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// CountResponse is the response body of Count.
+type CountResponse struct {
+	Count uint64 `json:"count"`
+}
+
+// MarkAllReadRequest is the request body of MarkAllRead.
+type MarkAllReadRequest struct {
+	Repo       string    `json:"repo,omitempty"` // Empty means all repos.
+	LastReadAt time.Time `json:"last_read_at"`   // Zero means no cutoff.
+}
+
+// MarkReadBatchRequest is the request body of MarkReadBatch.
+type MarkReadBatchRequest struct {
+	Threads []Thread `json:"threads"`
+}
+
+// SnoozeRequest is the request body of Snooze.
+type SnoozeRequest struct {
+	Thread
+	Until time.Time `json:"until"`
+}
+
+// SetSavedRequest is the request body of SetSaved.
+type SetSavedRequest struct {
+	Thread
+	Saved bool `json:"saved"`
+}
+
+// SubscribeRequest is the request body of Subscribe, Unsubscribe and Ignore.
+type SubscribeRequest struct {
+	Thread
+	Subscribers []UserSpec `json:"subscribers"`
+}
+
+// NotifyRequest is the request body of Notify.
+type NotifyRequest struct {
+	Thread
+	NotificationRequest NotificationRequest `json:"notification"`
+}
+
+// Error is the response body of failed requests.
+type Error struct {
+	Error string `json:"error"`
+
+	// Code identifies errors that clients can tell apart,
+	// such as ErrorCodeInvalidCursor. Empty for other errors.
+	Code string `json:"code,omitempty"`
+}
+
+// ErrorCodeInvalidCursor is the Error.Code of 400 Bad Request
+// responses caused by notifications.ErrInvalidCursor.
+const ErrorCodeInvalidCursor = "invalid_cursor"
+
+// parseColor parses a hexadecimal color like "#ff0000".
+// It returns black if s is not a valid color.
+func parseColor(s string) notifications.RGB {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != len("#rrggbb") {
+		return notifications.RGB{}
+	}
+	return notifications.RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}
+}
+
+// marshalUserSpec formats us as a string like "1@example.com".
+func marshalUserSpec(us users.UserSpec) string {
+	return fmt.Sprintf("%d@%s", us.ID, us.Domain)
+}
+
+// unmarshalUserSpec parses a string like "1@example.com".
+func unmarshalUserSpec(s string) (users.UserSpec, error) {
+	parts := strings.SplitN(s, "@", 2)
+	if len(parts) != 2 {
+		return users.UserSpec{}, fmt.Errorf("user spec is not 2 parts: %v", len(parts))
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return users.UserSpec{}, err
+	}
+	return users.UserSpec{ID: id, Domain: parts[1]}, nil
+}
@@ -0,0 +1,82 @@
+// Package apitest contains the notifications HTTP API setup
+// shared by tests of packages httphandler and httpclient.
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/notifications/fs"
+	"github.com/shurcooL/notifications/httphandler"
+	"github.com/shurcooL/users"
+	"golang.org/x/net/webdav"
+)
+
+// NewHandler returns a handler serving an fs-backed service,
+// where the X-User header is the ID of the authenticated user.
+func NewHandler(t *testing.T) httphandler.Handler {
+	mem := webdav.NewMemFS()
+	for _, dir := range []string{"notifications", "read"} {
+		err := mem.Mkdir(context.Background(), dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return httphandler.Handler{
+		Notifications: fs.NewService(mem, Users{}),
+		Context: func(req *http.Request) (context.Context, error) {
+			if req.Header.Get("X-User") == "" {
+				return req.Context(), nil
+			}
+			id, err := strconv.ParseUint(req.Header.Get("X-User"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid X-User header: %v", err)
+			}
+			return WithUser(req.Context(), id), nil
+		},
+		Users: Users{},
+	}
+}
+
+// WithUser returns a copy of ctx where the user with id
+// at example.org is the authenticated user.
+func WithUser(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, userKey, users.UserSpec{ID: id, Domain: "example.org"})
+}
+
+type contextKey struct{}
+
+var userKey = contextKey{}
+
+// Users is a users.Service where the authenticated user comes from the context.
+// All users at example.org exist.
+type Users struct {
+	users.Service
+}
+
+func (Users) Get(_ context.Context, user users.UserSpec) (users.User, error) {
+	if user.Domain != "example.org" {
+		return users.User{}, fmt.Errorf("user %v not found", user)
+	}
+	return users.User{
+		UserSpec:    user,
+		CanonicalMe: fmt.Sprintf("https://example.org/gopher%d", user.ID),
+		Elsewhere:   []users.UserSpec{{ID: user.ID, Domain: "github.com"}},
+		Login:       fmt.Sprintf("gopher%d", user.ID),
+		Name:        fmt.Sprintf("Gopher %d", user.ID),
+		Email:       fmt.Sprintf("gopher%d@example.org", user.ID),
+		AvatarURL:   fmt.Sprintf("https://example.org/gopher%d.png", user.ID),
+		HTMLURL:     fmt.Sprintf("https://example.org/gopher%d", user.ID),
+		CreatedAt:   time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		SiteAdmin:   user.ID == 1,
+	}, nil
+}
+
+func (Users) GetAuthenticatedSpec(ctx context.Context) (users.UserSpec, error) {
+	us, _ := ctx.Value(userKey).(users.UserSpec)
+	return us, nil
+}
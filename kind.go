@@ -0,0 +1,53 @@
+package notifications
+
+// Kind is a semantic kind of notification, such as an open issue or a merged pull request.
+// Unlike an OcticonID, it stays stable in storage as icon sets change.
+type Kind string
+
+const (
+	KindIssueOpen   Kind = "issue-open"   // An open issue.
+	KindIssueClosed Kind = "issue-closed" // A closed issue.
+	KindPROpen      Kind = "pr-open"      // An open pull request.
+	KindPRClosed    Kind = "pr-closed"    // A pull request closed without merging.
+	KindPRMerged    Kind = "pr-merged"    // A merged pull request.
+	KindRelease     Kind = "release"      // A release.
+	KindCommit      Kind = "commit"       // A commit.
+	KindInvitation  Kind = "invitation"   // An invitation to a repository.
+)
+
+// Icons maps notification kinds to icons of an icon set,
+// such as Octicon IDs, emoji, or SVG markup.
+// Callers may define their own mappings.
+type Icons map[Kind]string
+
+// Icon returns the icon for kind k, or fallback if there isn't one.
+func (m Icons) Icon(k Kind, fallback string) string {
+	if icon, ok := m[k]; ok {
+		return icon
+	}
+	return fallback
+}
+
+// Octicons maps notification kinds to Octicon IDs.
+var Octicons = Icons{
+	KindIssueOpen:   "issue-opened",
+	KindIssueClosed: "issue-closed",
+	KindPROpen:      "git-pull-request",
+	KindPRClosed:    "git-pull-request",
+	KindPRMerged:    "git-pull-request",
+	KindRelease:     "tag",
+	KindCommit:      "git-commit",
+	KindInvitation:  "mail",
+}
+
+// Emoji maps notification kinds to emoji.
+var Emoji = Icons{
+	KindIssueOpen:   "🟢",
+	KindIssueClosed: "🔴",
+	KindPROpen:      "🔀",
+	KindPRClosed:    "⛔",
+	KindPRMerged:    "🟣",
+	KindRelease:     "🏷️",
+	KindCommit:      "📝",
+	KindInvitation:  "✉️",
+}
@@ -11,17 +11,51 @@ import (
 
 // Service for notifications.
 type Service interface {
-	// List notifications for authenticated user.
+	// List notifications for authenticated user, most recently updated first.
+	// If opt.PageSize is positive, at most that many notifications are listed,
+	// and nextCursor can be used to list the next page. nextCursor is empty
+	// if there are no more notifications.
+	//
+	// Pages are meant to be listed one after another while notifications change.
+	// A notification updated in between pages moves to the front, so later pages
+	// don't list it, whether or not an earlier page did. Marking notifications
+	// read doesn't move them, though implementations that page by offset
+	// may then list a notification twice or skip it.
+	//
+	// Returns a permission error if no authenticated user.
+	List(ctx context.Context, opt ListOptions) (_ Notifications, nextCursor string, _ error)
+
+	// Count unread notifications for authenticated user.
 	// Returns a permission error if no authenticated user.
-	List(ctx context.Context, opt ListOptions) (Notifications, error)
+	Count(ctx context.Context, opt CountOptions) (uint64, error)
 
-	// Count notifications for authenticated user.
+	// MarkAllRead marks all notifications matching opt as read.
 	// Returns a permission error if no authenticated user.
-	Count(ctx context.Context, opt interface{}) (uint64, error)
+	MarkAllRead(ctx context.Context, opt MarkAllReadOptions) error
+
+	// MarkUnread marks the specified thread as unread.
+	// Returns a permission error if no authenticated user,
+	// or ErrNotSupported if the service can't mark notifications unread.
+	MarkUnread(ctx context.Context, repo RepoSpec, threadType string, threadID uint64) error
 
-	// MarkAllRead marks all notifications in the specified repository as read.
+	// Delete deletes the notification of the specified thread, marking it as done.
+	// The thread is notified again on next Notify.
 	// Returns a permission error if no authenticated user.
-	MarkAllRead(ctx context.Context, repo RepoSpec) error
+	Delete(ctx context.Context, repo RepoSpec, threadType string, threadID uint64) error
+
+	// Snooze hides the notification of the specified thread until the given time,
+	// after which it's unread again. A newer Notify of the thread ends the snooze early.
+	// Implementations may only notice that a snooze has ended on the next List or Count,
+	// so the resulting ChangeCreated is not sent to watchers until then.
+	// Returns a permission error if no authenticated user,
+	// or ErrNotSupported if the service can't snooze notifications.
+	Snooze(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, until time.Time) error
+
+	// SetSaved sets whether the notification of the specified thread is saved.
+	// Saved notifications are kept after they're read, rather than eventually purged.
+	// Returns a permission error if no authenticated user,
+	// or ErrNotSupported if the service can't save notifications.
+	SetSaved(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, saved bool) error
 
 	ExternalService
 }
@@ -39,15 +73,53 @@ type ExternalService interface {
 	//        Or maybe MarkAllRead should be merged into MarkRead?
 	Subscribe(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error
 
+	// Unsubscribe unsubscribes subscribers from the specified thread,
+	// also undoing any Ignore of it.
+	// If threadType and threadID are zero, subscribers stop watching
+	// the entire repo.
+	// Returns a permission error if no authenticated user.
+	Unsubscribe(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error
+
+	// Ignore makes subscribers ignore the specified thread, so that they're
+	// not notified of it even if they're watching the entire repo.
+	// It lasts until they're subscribed to or unsubscribed from the thread.
+	// threadType and threadID must not be zero.
+	// Returns a permission error if no authenticated user.
+	Ignore(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, subscribers []users.UserSpec) error
+
 	// MarkRead marks the specified thread as read.
 	// Returns a permission error if no authenticated user.
 	MarkRead(ctx context.Context, repo RepoSpec, threadType string, threadID uint64) error
 
+	// MarkReadBatch marks the specified threads as read.
+	// Returns a permission error if no authenticated user.
+	MarkReadBatch(ctx context.Context, threads []ThreadRef) error
+
 	// Notify notifies subscribers of the specified thread of a notification.
 	// Returns a permission error if no authenticated user.
 	Notify(ctx context.Context, repo RepoSpec, threadType string, threadID uint64, nr NotificationRequest) error
 }
 
+// MarkAllReadOptions are options for MarkAllRead.
+type MarkAllReadOptions struct {
+	// Repo is an optional filter. If not nil, only notifications in Repo are marked read.
+	// Otherwise, notifications across all repositories are marked read.
+	Repo *RepoSpec
+
+	// LastReadAt is the time the user last saw their notifications,
+	// such as when the page listing them was loaded. If not zero, notifications
+	// that arrived after LastReadAt are left unread, so that they're not marked
+	// read without the user having seen them. Zero means now.
+	LastReadAt time.Time
+}
+
+// ThreadRef is a reference to a thread.
+type ThreadRef struct {
+	RepoSpec   RepoSpec
+	ThreadType string
+	ThreadID   uint64
+}
+
 // CopierFrom is an optional interface that allows copying notifications between services.
 type CopierFrom interface {
 	// CopyFrom copies all accessible notifications from src to dst user.
@@ -55,6 +127,144 @@ type CopierFrom interface {
 	CopyFrom(ctx context.Context, src Service, dst users.UserSpec) error
 }
 
+// SubscriptionLister is an optional interface that allows reading back subscriptions.
+type SubscriptionLister interface {
+	// ListSubscribers lists users that are notified of the specified thread,
+	// either because they're subscribed to it, or because they're watching the entire repo.
+	// If threadType and threadID are zero, only users watching the entire repo are listed.
+	// Returns a permission error if no authenticated user.
+	ListSubscribers(ctx context.Context, repo RepoSpec, threadType string, threadID uint64) ([]Subscriber, error)
+
+	// ListSubscriptions lists repos and threads that user is subscribed to,
+	// including threads they ignore.
+	// Returns a permission error if user is not the authenticated user.
+	ListSubscriptions(ctx context.Context, user users.UserSpec) ([]Subscription, error)
+}
+
+// Subscriber represents a user that is notified of a thread.
+type Subscriber struct {
+	User          users.UserSpec
+	Participating bool // Whether user is subscribed to the thread, or just watching the entire repo.
+}
+
+// Subscription represents a repo or thread that a user is subscribed to.
+type Subscription struct {
+	RepoSpec   RepoSpec
+	ThreadType string // Zero when watching the entire repo.
+	ThreadID   uint64 // Zero when watching the entire repo.
+	Ignored    bool   // Whether the thread is ignored, rather than subscribed to.
+
+	Watch RepoWatch // Level of watching the entire repo. Only set for repo subscriptions.
+}
+
+// RepoWatchService is an optional interface that allows setting the level
+// at which users watch repositories.
+type RepoWatchService interface {
+	// SetRepoWatch sets the level at which subscribers watch repo.
+	// Subscribe with zero threadType and threadID is equivalent to WatchAll,
+	// and Unsubscribe with them is equivalent to WatchParticipating.
+	// Returns a permission error if no authenticated user.
+	SetRepoWatch(ctx context.Context, repo RepoSpec, subscribers []users.UserSpec, w RepoWatch) error
+
+	// GetRepoWatch returns the level at which user watches repo.
+	// Returns a permission error if user is not the authenticated user.
+	GetRepoWatch(ctx context.Context, repo RepoSpec, user users.UserSpec) (RepoWatch, error)
+}
+
+// RepoWatch is the level at which a user watches a repository.
+type RepoWatch struct {
+	Level WatchLevel
+
+	// ThreadTypes are the types of threads watched with WatchCustom.
+	// For example, only releases or only pull requests.
+	ThreadTypes []string
+}
+
+// Watching reports whether w watches all activity in threads of threadType,
+// rather than only threads the user participates in.
+func (w RepoWatch) Watching(threadType string) bool {
+	switch w.Level {
+	case WatchAll:
+		return true
+	case WatchCustom:
+		return containsString(w.ThreadTypes, threadType)
+	default:
+		return false
+	}
+}
+
+// WatchLevel is a level of watching a repository.
+type WatchLevel string
+
+const (
+	WatchAll           WatchLevel = "all"           // Notified of all activity.
+	WatchParticipating WatchLevel = "participating" // Notified only of threads the user participates in.
+	WatchIgnore        WatchLevel = "ignore"        // Never notified, not even of threads the user participates in.
+	WatchCustom        WatchLevel = "custom"        // Notified of all activity in threads of some types only.
+)
+
+// ThreadEventLister is an optional interface that allows listing
+// the individual events of a thread that a user was notified of.
+type ThreadEventLister interface {
+	// ListThreadEvents lists events of the specified thread that the authenticated user
+	// was notified of, oldest first. Events since the thread was last read are unread.
+	// Returns a permission error if no authenticated user.
+	ListThreadEvents(ctx context.Context, repo RepoSpec, threadType string, threadID uint64) ([]ThreadEvent, error)
+}
+
+// ThreadEvent represents an event of a thread, such as a new comment.
+type ThreadEvent struct {
+	Title     string
+	Actor     users.User
+	CreatedAt time.Time
+	Read      bool
+	HTMLURL   string // Address of event target.
+	Body      string // Optional plain text or Markdown excerpt of the content, for previews.
+}
+
+// Watcher is an optional interface that allows watching for changes to notifications.
+type Watcher interface {
+	// Watch watches for changes to notifications of authenticated user.
+	// Changes are sent to the returned channel, which is closed when ctx is done.
+	//
+	// Implementations may drop changes if the receiver doesn't keep up.
+	// In that case, a Change with Op ChangeResync is sent after the last change
+	// that wasn't dropped, and the receiver should List notifications again.
+	//
+	// The end of a snooze may only be noticed when notifications are next listed or counted,
+	// rather than as soon as it ends. See Service.Snooze.
+	//
+	// Returns a permission error if no authenticated user.
+	Watch(ctx context.Context) (<-chan Change, error)
+}
+
+// Change represents a change to a notification.
+type Change struct {
+	Op         ChangeOp
+	RepoSpec   RepoSpec
+	ThreadType string
+	ThreadID   uint64
+
+	// Notification is the notification after the change.
+	// It's only set when Op is ChangeCreated or ChangeUpdated.
+	Notification *Notification
+}
+
+// ChangeOp is the kind of change to a notification.
+type ChangeOp string
+
+const (
+	ChangeCreated ChangeOp = "created" // A new unread notification was created.
+	ChangeUpdated ChangeOp = "updated" // An existing unread notification was updated.
+	ChangeRead    ChangeOp = "read"    // A notification was marked read.
+	ChangeUnread  ChangeOp = "unread"  // A notification was marked unread.
+	ChangeSnoozed ChangeOp = "snoozed" // A notification was snoozed.
+	ChangeSaved   ChangeOp = "saved"   // A notification was saved.
+	ChangeUnsaved ChangeOp = "unsaved" // A notification was unsaved.
+	ChangeDeleted ChangeOp = "deleted" // A notification was deleted.
+	ChangeResync  ChangeOp = "resync"  // Changes were dropped. Other fields are zero.
+)
+
 // ListOptions are options for List operation.
 type ListOptions struct {
 	// Repo is an optional filter. If not nil, only notifications from Repo will be listed.
@@ -62,6 +272,60 @@ type ListOptions struct {
 
 	// All specifies whether to include read notifications in addition to unread ones.
 	All bool
+
+	// PageSize is the maximum number of notifications to list.
+	// Zero means there is no limit, and all notifications are listed.
+	PageSize int
+
+	// Cursor is an opaque cursor returned by a previous List call,
+	// used to continue listing from where that call stopped.
+	// Empty means listing starts from the beginning.
+	// List returns an error wrapping ErrInvalidCursor if Cursor is malformed.
+	Cursor string
+
+	// Since is an optional filter. If not zero, only notifications updated after Since will be listed.
+	Since time.Time
+
+	// Before is an optional filter. If not zero, only notifications updated before Before will be listed.
+	Before time.Time
+
+	// ThreadType is an optional filter. If not empty, only notifications of ThreadType will be listed.
+	ThreadType string
+
+	// Reason is an optional filter. If not empty, only notifications with Reason will be listed.
+	Reason Reason
+
+	// Participating specifies whether to list only notifications in threads
+	// the user is participating in, rather than just watching.
+	Participating bool
+
+	// Mentioned specifies whether to list only notifications where
+	// the user was specifically @mentioned.
+	Mentioned bool
+
+	// Saved specifies whether to list only saved notifications.
+	// List returns ErrNotSupported if the service can't save notifications.
+	Saved bool
+
+	// Actor is an optional filter. If not nil, only notifications triggered by Actor will be listed.
+	Actor *users.UserSpec
+}
+
+// CountOptions are options for Count operation.
+type CountOptions struct {
+	// Repo is an optional filter. If not nil, only notifications from Repo will be counted.
+	Repo *RepoSpec
+
+	// ThreadType is an optional filter. If not empty, only notifications of ThreadType will be counted.
+	ThreadType string
+
+	// Participating specifies whether to count only notifications in threads
+	// the user is participating in, rather than just watching.
+	Participating bool
+
+	// Mentioned specifies whether to count only notifications where
+	// the user was specifically @mentioned.
+	Mentioned bool
 }
 
 // Notification represents a notification.
@@ -70,27 +334,78 @@ type Notification struct {
 	ThreadType string
 	ThreadID   uint64
 	Title      string
-	Icon       OcticonID // TODO: Some notifications can exist for a long time. OcticonID may change when frontend updates to newer versions of octicons. Think of a better long term solution?
+	Kind       Kind
+	State      SubjectState // State of the notification subject, if known.
+	Icon       OcticonID    // Deprecated: Use Kind, mapped to an icon set such as Octicons. It's set from Kind when known.
 	Color      RGB
-	Actor      users.User
+	Actor      users.User   // Actor of the most recent event.
+	Actors     []users.User // Distinct actors of events since the thread was last read, most recent first.
+	EventCount int          // Number of events since the thread was last read.
 	UpdatedAt  time.Time
 	Read       bool
 	HTMLURL    string // Address of notification target.
+	Body       string // Optional plain text or Markdown excerpt of the content, for previews.
 
-	Participating bool // Whether user is participating in the thread, or just watching.
-	Mentioned     bool // Whether user was specifically @mentioned in the content.
+	Reason        Reason // Reason the user was notified.
+	Participating bool   // Whether user is participating in the thread, or just watching.
+	Mentioned     bool   // Whether user was specifically @mentioned in the content.
+	Saved         bool   // Whether user saved the notification, to keep it after it's read.
 }
 
 // NotificationRequest represents a request to create a notification.
 type NotificationRequest struct {
 	Title     string
-	Icon      OcticonID
+	Kind      Kind
+	State     SubjectState // State of the notification subject, if known.
+	Icon      OcticonID    // Deprecated: Use Kind. Used only if Kind is empty.
 	Color     RGB
 	Actor     users.UserSpec // Actor that triggered the notification. TODO: Maybe not needed? Why not use current user?
 	UpdatedAt time.Time      // TODO: Maybe not needed? Why not use time.Now()? Could do it, but time.Now() will be slightly later than original request time.
 	HTMLURL   string         // Address of notification target.
+	Body      string         // Optional plain text or Markdown excerpt of the content, for previews.
+
+	Mentions []users.UserSpec // Users specifically @mentioned in the content, if any.
+
+	// Reason is the reason subscribers that participate in the thread are notified.
+	// Mentioned users are notified with ReasonMention, and users just watching
+	// the repo are notified with ReasonSubscribed, regardless of Reason.
+	Reason Reason
 }
 
+// Reason is the reason a user was notified.
+// Its values match the notification reasons of GitHub.
+type Reason string
+
+const (
+	ReasonApprovalRequested      Reason = "approval_requested"       // User was requested to review and approve a deployment.
+	ReasonAssign                 Reason = "assign"                   // User was assigned to the thread.
+	ReasonAuthor                 Reason = "author"                   // User created the thread.
+	ReasonCIActivity             Reason = "ci_activity"              // A workflow run triggered by user was completed.
+	ReasonComment                Reason = "comment"                  // User commented on the thread.
+	ReasonInvitation             Reason = "invitation"               // User accepted an invitation to contribute to the repo.
+	ReasonManual                 Reason = "manual"                   // User subscribed to the thread manually.
+	ReasonMemberFeatureRequested Reason = "member_feature_requested" // Organization members requested a feature.
+	ReasonMention                Reason = "mention"                  // User was specifically @mentioned in the content.
+	ReasonReviewRequested        Reason = "review_requested"         // User, or a team they're a member of, was requested to review a pull request.
+	ReasonSecurityAdvisoryCredit Reason = "security_advisory_credit" // User was credited for contributing to a security advisory.
+	ReasonSecurityAlert          Reason = "security_alert"           // A security vulnerability was discovered in the repo.
+	ReasonStateChange            Reason = "state_change"             // User changed the thread state.
+	ReasonSubscribed             Reason = "subscribed"               // User is watching the repo.
+	ReasonTeamMention            Reason = "team_mention"             // User is on a team that was mentioned.
+)
+
+// SubjectState is the state of the subject of a notification,
+// such as an issue or pull request.
+type SubjectState string
+
+const (
+	SubjectStateOpen       SubjectState = "open"       // An open issue or pull request.
+	SubjectStateClosed     SubjectState = "closed"     // A closed issue, or a pull request closed without merging.
+	SubjectStateMerged     SubjectState = "merged"     // A merged pull request.
+	SubjectStateDraft      SubjectState = "draft"      // An open pull request that's a draft.
+	SubjectStatePrerelease SubjectState = "prerelease" // A release that's a pre-release.
+)
+
 // Octicon ID. E.g., "issue-opened".
 type OcticonID string
 
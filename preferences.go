@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/shurcooL/users"
+)
+
+// PreferencesService is an optional interface that allows getting and setting
+// notification delivery preferences of users.
+type PreferencesService interface {
+	// GetPreferences returns the preferences of user.
+	// Users who never set preferences get the zero value.
+	// Returns a permission error if user is not the authenticated user.
+	GetPreferences(ctx context.Context, user users.UserSpec) (Preferences, error)
+
+	// SetPreferences sets the preferences of user.
+	// Returns a permission error if user is not the authenticated user.
+	SetPreferences(ctx context.Context, user users.UserSpec, p Preferences) error
+}
+
+// Preferences are notification delivery preferences of a user.
+// The zero value delivers all notifications to the inbox.
+type Preferences struct {
+	// Delivery is used for notifications in repos without an override.
+	Delivery Delivery
+
+	// Repos are per-repo overrides. A repo's override is used
+	// in place of Delivery for notifications in that repo.
+	Repos map[RepoSpec]Delivery
+}
+
+// For returns the delivery preferences that apply to notifications in repo.
+func (p Preferences) For(repo RepoSpec) Delivery {
+	if d, ok := p.Repos[repo]; ok {
+		return d
+	}
+	return p.Delivery
+}
+
+// Delivery are preferences for which notifications are delivered, and where.
+// Services deliver to the channels they support, and other channels are left
+// to other components, which can consult the preferences via PreferencesService.
+type Delivery struct {
+	// Reasons is an optional filter. If not empty, only notifications
+	// with one of Reasons are delivered.
+	Reasons []Reason
+
+	// ThreadTypes is an optional filter. If not empty, only notifications
+	// of one of ThreadTypes are delivered.
+	ThreadTypes []string
+
+	// Channels are the channels to deliver notifications on.
+	// If empty, notifications are delivered to ChannelInbox only.
+	Channels []Channel
+}
+
+// Wants reports whether a notification with reason and threadType
+// is to be delivered on channel c.
+func (d Delivery) Wants(c Channel, reason Reason, threadType string) bool {
+	if !d.has(c) {
+		return false
+	}
+	if len(d.Reasons) > 0 && !containsReason(d.Reasons, reason) {
+		return false
+	}
+	if len(d.ThreadTypes) > 0 && !containsString(d.ThreadTypes, threadType) {
+		return false
+	}
+	return true
+}
+
+// has reports whether d delivers on channel c.
+func (d Delivery) has(c Channel) bool {
+	if len(d.Channels) == 0 {
+		return c == ChannelInbox
+	}
+	for _, ch := range d.Channels {
+		if ch == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Channel is a channel that notifications are delivered on.
+type Channel string
+
+const (
+	ChannelInbox   Channel = "inbox"   // The notifications inbox, as listed by Service.List.
+	ChannelEmail   Channel = "email"   // Email.
+	ChannelWebhook Channel = "webhook" // A webhook.
+)
+
+func containsReason(rs []Reason, r Reason) bool {
+	for _, v := range rs {
+		if v == r {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}